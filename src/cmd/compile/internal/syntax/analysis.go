@@ -0,0 +1,1542 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements syntactic analyses of syntax trees.
+// Since no type information is available, some of them
+// are necessarily heuristic.
+
+package syntax
+
+import (
+	"go/constant"
+	gotoken "go/token"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ChannelOps returns the send statements and the receive operations
+// in the tree rooted at root, in source order. Receive operations are
+// the unary <- operations, including those on the right-hand side of
+// (possibly two-valued) assignments and in select cases. Range loops
+// over channels cannot be identified without type information and are
+// not reported.
+func ChannelOps(root Node) (sends []*SendStmt, receives []Expr) {
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *SendStmt:
+			sends = append(sends, n)
+		case *Operation:
+			if n.Op == Recv && n.Y == nil {
+				receives = append(receives, n)
+			}
+		}
+		return true
+	})
+	return
+}
+
+// StringConcatInLoops returns the assignments s += x and s = s + x
+// within loops of fn that likely build a string incrementally, in
+// source order. Since there is no type information, an assignment is
+// reported if s is a variable known to be a string because it is
+// declared in fn with type string or initialized with a string literal,
+// or if the added operand contains a string literal. Only assignments
+// executed per iteration are considered: those in the condition, post
+// statement, or body of a loop, but not in its init statement. Loops in
+// function literals are considered separately from enclosing loops.
+func StringConcatInLoops(fn *FuncDecl) []*AssignStmt {
+	strvars := make(map[string]bool) // names of likely string variables
+	declare := func(name *Name, typ, val Expr) {
+		if isNameOf(typ, "string") || isStringLit(val) {
+			strvars[name.Value] = true
+		}
+	}
+	Inspect(fn, func(n Node) bool {
+		switch n := n.(type) {
+		case *Field:
+			if n.Name != nil {
+				declare(n.Name, n.Type, nil)
+			}
+		case *VarDecl:
+			values := UnpackListExpr(n.Values)
+			for i, name := range n.NameList {
+				var val Expr
+				if len(values) == len(n.NameList) {
+					val = values[i]
+				}
+				declare(name, n.Type, val)
+			}
+		case *AssignStmt:
+			if n.Op == Def {
+				lhs, rhs := UnpackListExpr(n.Lhs), UnpackListExpr(n.Rhs)
+				for i, x := range lhs {
+					if name, ok := x.(*Name); ok && len(lhs) == len(rhs) {
+						declare(name, nil, rhs[i])
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	var list []*AssignStmt
+	if fn.Body != nil {
+		inspectLoops(fn.Body, func(n Node, inLoop bool) {
+			if s, ok := n.(*AssignStmt); ok && inLoop && isConcat(s) && (strvars[s.Lhs.(*Name).Value] || containsStringLit(s.Rhs)) {
+				list = append(list, s)
+			}
+		})
+	}
+	return list
+}
+
+// inspectLoops calls f for each node n of the tree rooted at root, in
+// pre-order, with inLoop reporting whether n is evaluated once per
+// iteration of a loop: n is (part of) the condition, post statement, or
+// body of a for statement, but not of its init statement or range
+// expression. Function literals within a loop start without a loop.
+func inspectLoops(root Node, f func(n Node, inLoop bool)) {
+	var inspect func(n Node, inLoop bool)
+	inspect = func(n Node, inLoop bool) {
+		f(n, inLoop)
+		switch n := n.(type) {
+		case *ForStmt:
+			ChildrenFunc(n, func(c Node) bool {
+				inspect(c, inLoop || c == n.Cond || c == n.Post || c == n.Body)
+				return true
+			})
+			return
+		case *FuncLit:
+			inLoop = false
+		}
+		ChildrenFunc(n, func(c Node) bool {
+			inspect(c, inLoop)
+			return true
+		})
+	}
+	inspect(root, false)
+}
+
+// isConcat reports whether the assignment s has the
+// form x += y or x = x + y where x is an identifier.
+func isConcat(s *AssignStmt) bool {
+	lhs, ok := s.Lhs.(*Name)
+	if !ok || s.Rhs == nil {
+		return false
+	}
+	switch s.Op {
+	case Add:
+		return true
+	case 0:
+		// x = x + a + b is parsed as (x + a) + b
+		x := s.Rhs
+		for {
+			op, ok := x.(*Operation)
+			if !ok || op.Op != Add || op.Y == nil {
+				break
+			}
+			x = op.X
+		}
+		return x != s.Rhs && isNameOf(x, lhs.Value)
+	}
+	return false
+}
+
+// AppendInLoopWithoutCap returns the assignments s = append(s, ...) in
+// the bodies and post statements of loops of fn, where s is an
+// identifier, which append to a slice that was not allocated with a
+// capacity hint, as in make([]T, 0, n), before the assignment in fn.
+// Such slices are possibly grown repeatedly and could be preallocated.
+// Statements in function literals are only considered if the literal
+// itself contains the loop. The assignments are returned in source
+// order. Variables are identified by name.
+func AppendInLoopWithoutCap(fn *FuncDecl) []*AssignStmt {
+	if fn.Body == nil {
+		return nil
+	}
+
+	// prealloc maps variable names to the position of the
+	// first allocation of the variable with a capacity hint
+	prealloc := make(map[string]Pos)
+	alloc := func(lhs, rhs []Expr) {
+		if len(lhs) != len(rhs) {
+			return
+		}
+		for i, x := range lhs {
+			name, ok := Unparen(x).(*Name)
+			if !ok || !isMakeWithCap(rhs[i]) {
+				continue
+			}
+			if _, found := prealloc[name.Value]; !found {
+				prealloc[name.Value] = name.Pos()
+			}
+		}
+	}
+	Inspect(fn.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case *VarDecl:
+			var lhs []Expr
+			for _, name := range n.NameList {
+				lhs = append(lhs, name)
+			}
+			alloc(lhs, UnpackListExpr(n.Values))
+		case *AssignStmt:
+			if n.Op == 0 || n.Op == Def {
+				alloc(UnpackListExpr(n.Lhs), UnpackListExpr(n.Rhs))
+			}
+		}
+		return true
+	})
+
+	var list []*AssignStmt
+	inspectLoops(fn.Body, func(n Node, inLoop bool) {
+		if s, ok := n.(*AssignStmt); ok && inLoop && isSelfAppend(s) {
+			pos, found := prealloc[s.Lhs.(*Name).Value]
+			if !found || pos.Cmp(s.Pos()) > 0 {
+				list = append(list, s)
+			}
+		}
+	})
+	return list
+}
+
+// TimeAfterInLoops returns the calls time.After(d) in the conditions,
+// post statements, and bodies of loops of fn (but not in their init
+// statements or range expressions), including those in select
+// statements within loops, as in
+//
+//	for {
+//		select {
+//		case v := <-ch:
+//			...
+//		case <-time.After(timeout):
+//			...
+//		}
+//	}
+//
+// in source order. Each such call creates a new timer per iteration,
+// which (before Go 1.23) is not garbage collected until it fires; a
+// time.Timer or time.Ticker created outside the loop and reset as needed
+// is usually preferable. As for AppendInLoopWithoutCap, calls in function
+// literals are only considered if the literal itself contains the loop.
+// The package name time is matched syntactically.
+func TimeAfterInLoops(fn *FuncDecl) []*CallExpr {
+	if fn.Body == nil {
+		return nil
+	}
+	var list []*CallExpr
+	inspectLoops(fn.Body, func(n Node, inLoop bool) {
+		if call, ok := n.(*CallExpr); ok && inLoop && isQualified(Unparen(call.Fun), "time", "After") {
+			list = append(list, call)
+		}
+	})
+	return list
+}
+
+// isSelfAppend reports whether s has the form
+// x = append(x, ...) where x is an identifier.
+func isSelfAppend(s *AssignStmt) bool {
+	lhs, ok := s.Lhs.(*Name)
+	if !ok || s.Op != 0 {
+		return false
+	}
+	call := appendCall(s.Rhs)
+	return call != nil && len(call.ArgList) > 0 && isNameOf(Unparen(call.ArgList[0]), lhs.Value)
+}
+
+// isMakeWithCap reports whether x is a call make(T, len, cap).
+func isMakeWithCap(x Expr) bool {
+	call, ok := Unparen(x).(*CallExpr)
+	return ok && isNameOf(Unparen(call.Fun), "make") && len(call.ArgList) == 3
+}
+
+// isNameOf reports whether x is the identifier name.
+func isNameOf(x Expr, name string) bool {
+	n, ok := x.(*Name)
+	return ok && n.Value == name
+}
+
+// isStringLit reports whether x is a string literal.
+func isStringLit(x Expr) bool {
+	lit, ok := x.(*BasicLit)
+	return ok && lit.Kind == StringLit
+}
+
+// containsStringLit reports whether x contains a string literal.
+func containsStringLit(x Expr) bool {
+	found := false
+	Inspect(x, func(n Node) bool {
+		if x, ok := n.(Expr); ok && isStringLit(x) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// IgnoredErrors returns the statements in the tree rooted at root which
+// may ignore an error result, in source order: assignments (including
+// short variable declarations) of a single call to several variables
+// where the last one, conventionally holding an error, is the blank
+// identifier, as in x, _ := f(); assignments of a call to a single blank
+// identifier, as in _ = f(); and expression statements consisting of a
+// call, whose results are dropped entirely. Without type information,
+// the latter includes calls of functions that have no results; calls
+// of predeclared functions without results (such as panic or close)
+// are excluded. Calls deferred or started as goroutines are not reported.
+func IgnoredErrors(root Node) []Node {
+	var list []Node
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *AssignStmt:
+			if n.Op != 0 && n.Op != Def {
+				break
+			}
+			rhs := UnpackListExpr(n.Rhs)
+			if len(rhs) != 1 {
+				break
+			}
+			if _, ok := Unparen(rhs[0]).(*CallExpr); !ok {
+				break
+			}
+			lhs := UnpackListExpr(n.Lhs)
+			if isNameOf(lhs[len(lhs)-1], "_") {
+				list = append(list, n)
+			}
+		case *ExprStmt:
+			if call, ok := Unparen(n.X).(*CallExpr); ok && !isNoResultBuiltin(call.Fun) {
+				list = append(list, n)
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// isNoResultBuiltin reports whether fun is the name of a
+// predeclared function which has no result.
+func isNoResultBuiltin(fun Expr) bool {
+	if name, ok := Unparen(fun).(*Name); ok {
+		switch name.Value {
+		case "clear", "close", "delete", "panic", "print", "println":
+			return true
+		}
+	}
+	return false
+}
+
+// MethodCandidates returns the functions (not methods) declared in file
+// whose first parameter is of a type declared at package level in file,
+// or a pointer to such a type, in source order. Such functions may be
+// candidates for methods of that type.
+func MethodCandidates(file *File) []*FuncDecl {
+	types := make(map[string]bool)
+	for _, d := range file.DeclList {
+		if d, ok := d.(*TypeDecl); ok {
+			types[d.Name.Value] = true
+		}
+	}
+
+	var list []*FuncDecl
+	for _, d := range file.DeclList {
+		fn, ok := d.(*FuncDecl)
+		if !ok || fn.Recv != nil || len(fn.Type.ParamList) == 0 {
+			continue
+		}
+		if name := baseTypeName(fn.Type.ParamList[0].Type); name != nil && types[name.Value] {
+			list = append(list, fn)
+		}
+	}
+	return list
+}
+
+// UnusedReceivers returns the methods declared in file whose body
+// does not refer to the receiver, in source order. This includes
+// methods with an unnamed or blank receiver, but not methods without
+// a body.
+func UnusedReceivers(file *File) []*FuncDecl {
+	var list []*FuncDecl
+	for _, d := range file.DeclList {
+		fn, ok := d.(*FuncDecl)
+		if !ok || fn.Recv == nil || fn.Body == nil {
+			continue
+		}
+		recv := fn.Recv.Name
+		used := false
+		if recv != nil && recv.Value != "_" {
+			r := resolver{
+				use: func(_, decl *Name) {
+					if decl == recv {
+						used = true
+					}
+				},
+			}
+			r.resolve(fn)
+		}
+		if !used {
+			list = append(list, fn)
+		}
+	}
+	return list
+}
+
+// ReceiverType returns the name of the base type of the receiver of the
+// method fn, as in T for receivers of type T, *T, or *T[P], or nil if fn
+// is not a method or the receiver type has a different (invalid) form.
+func ReceiverType(fn *FuncDecl) *Name {
+	if fn.Recv == nil {
+		return nil
+	}
+	return baseTypeName(fn.Recv.Type)
+}
+
+// InconsistentReceivers returns the methods declared in file grouped by
+// the name of their receiver type (see ReceiverType), for the types whose
+// methods use different receiver names, as in t *Tree and tree *Tree. The
+// methods of each group are in source order. Methods with an unnamed or
+// blank receiver are not considered.
+func InconsistentReceivers(file *File) map[string][]*FuncDecl {
+	groups := make(map[string][]*FuncDecl)
+	for _, d := range file.DeclList {
+		fn, ok := d.(*FuncDecl)
+		if !ok || fn.Recv == nil || fn.Recv.Name == nil || fn.Recv.Name.Value == "_" {
+			continue
+		}
+		if typ := ReceiverType(fn); typ != nil {
+			groups[typ.Value] = append(groups[typ.Value], fn)
+		}
+	}
+	for typ, list := range groups {
+		consistent := true
+		for _, fn := range list[1:] {
+			if fn.Recv.Name.Value != list[0].Recv.Name.Value {
+				consistent = false
+				break
+			}
+		}
+		if consistent {
+			delete(groups, typ)
+		}
+	}
+	return groups
+}
+
+// baseTypeName returns the type name of the (possibly parenthesized)
+// type expression x, which may be a pointer to a type or an instantiated
+// generic type, such as T, *T, or *T[P]; or nil if there is no such name.
+func baseTypeName(x Expr) *Name {
+	x = Unparen(x)
+	if op, ok := x.(*Operation); ok && op.Op == Mul && op.Y == nil {
+		x = Unparen(op.X)
+	}
+	if ix, ok := x.(*IndexExpr); ok {
+		x = ix.X
+	}
+	name, _ := x.(*Name)
+	return name
+}
+
+// RedundantConversions returns the conversions in the tree rooted at
+// root whose operand is the same conversion, as in T(T(x)), in source
+// order. Without type information, only conversions to a type literal
+// (such as []byte or (*T)), to a predeclared type such as int or string,
+// or to a type declared in root are recognized; calls with a (possibly
+// qualified) name that does not denote such a type are assumed to be
+// function calls. The type expressions are compared textually.
+func RedundantConversions(root Node) []*CallExpr {
+	types := make(map[string]bool)
+	Inspect(root, func(n Node) bool {
+		if d, ok := n.(*TypeDecl); ok {
+			types[d.Name.Value] = true
+		}
+		return true
+	})
+
+	var list []*CallExpr
+	Inspect(root, func(n Node) bool {
+		if x, ok := n.(*CallExpr); ok && isConversion(x, types) {
+			if y, ok := Unparen(x.ArgList[0]).(*CallExpr); ok && isConversion(y, types) && String(x.Fun) == String(y.Fun) {
+				list = append(list, x)
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// isConversion reports whether the call x is syntactically a conversion
+// to a type literal, a predeclared type, or one of the named types.
+func isConversion(x *CallExpr, types map[string]bool) bool {
+	if len(x.ArgList) != 1 || x.HasDots {
+		return false
+	}
+	switch fun := Unparen(x.Fun).(type) {
+	case *Name:
+		return types[fun.Value] || isPredeclaredType(fun.Value)
+	case *Operation:
+		return fun.Op == Mul && fun.Y == nil // (*T)(x)
+	case *ArrayType, *SliceType, *MapType, *ChanType, *FuncType, *StructType, *InterfaceType:
+		return true
+	}
+	return false
+}
+
+// SuspiciousStringConversions returns the conversions string(x) in the
+// tree rooted at root whose operand x is likely an integer, in source
+// order. Such a conversion yields the UTF-8 encoding of the rune with the
+// integer value, rather than its decimal representation, as produced by
+// strconv.Itoa. Without type information, an operand is considered an
+// integer if it is an integer literal, a call of len or cap, a conversion
+// to an integer type other than byte (uint8) or rune (int32), or an
+// arithmetic operation with such an operand (the left operand, for
+// shifts) and no rune literal operand; conversions of integer variables,
+// as in string(i), are not recognized. Likewise, a local declaration of
+// string shadowing the predeclared type is not recognized.
+func SuspiciousStringConversions(root Node) []*CallExpr {
+	var list []*CallExpr
+	Inspect(root, func(n Node) bool {
+		if x, ok := n.(*CallExpr); ok && isNameOf(Unparen(x.Fun), "string") && len(x.ArgList) == 1 && !x.HasDots && isIntExpr(x.ArgList[0]) {
+			list = append(list, x)
+		}
+		return true
+	})
+	return list
+}
+
+// isIntExpr reports whether x is likely an integer;
+// see SuspiciousStringConversions.
+func isIntExpr(x Expr) bool {
+	switch x := Unparen(x).(type) {
+	case *BasicLit:
+		return x.Kind == IntLit
+	case *Operation:
+		switch x.Op {
+		case Add, Sub, Xor:
+			if x.Y == nil {
+				return isIntExpr(x.X)
+			}
+			fallthrough
+		case Mul, Div, Rem, Or, And, AndNot:
+			return (isIntExpr(x.X) || isIntExpr(x.Y)) && !isRuneLit(x.X) && !isRuneLit(x.Y)
+		case Shl, Shr:
+			return isIntExpr(x.X)
+		}
+	case *CallExpr:
+		if len(x.ArgList) != 1 || x.HasDots {
+			return false
+		}
+		if fun, ok := Unparen(x.Fun).(*Name); ok {
+			switch fun.Value {
+			case "len", "cap", "int", "int8", "int16", "int64", "uint", "uint16", "uint32", "uint64", "uintptr":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRuneLit reports whether x is a (possibly parenthesized) rune literal.
+func isRuneLit(x Expr) bool {
+	lit, ok := Unparen(x).(*BasicLit)
+	return ok && lit.Kind == RuneLit
+}
+
+// ConstOverflowConversions returns the conversions in the tree rooted at
+// root of an integer or rune literal (possibly negated) to a fixed-size
+// integer type, such as int8(300) or uint8(-1), where the value of the
+// literal lies outside the range of the type, which is a compile-time
+// error, in source order. The sized integer types are recognized by the
+// names of the predeclared types (see sizedIntTypes); int, uint, and
+// uintptr, whose size depends on the platform, are not considered, and
+// neither are local types or declarations shadowing the predeclared ones.
+func ConstOverflowConversions(root Node) []*CallExpr {
+	var list []*CallExpr
+	Inspect(root, func(n Node) bool {
+		call, ok := n.(*CallExpr)
+		if !ok || len(call.ArgList) != 1 || call.HasDots {
+			return true
+		}
+		fun, ok := Unparen(call.Fun).(*Name)
+		if !ok {
+			return true
+		}
+		typ, ok := sizedIntTypes[fun.Value]
+		if !ok {
+			return true
+		}
+		if v := intLitValue(call.ArgList[0]); v != nil && !typ.contains(v) {
+			list = append(list, call)
+		}
+		return true
+	})
+	return list
+}
+
+// A sizedInt describes a fixed-size integer type.
+type sizedInt struct {
+	bits   uint
+	signed bool
+}
+
+// sizedIntTypes maps the names of the predeclared
+// fixed-size integer types to their descriptions.
+var sizedIntTypes = map[string]sizedInt{
+	"int8":   {8, true},
+	"int16":  {16, true},
+	"int32":  {32, true},
+	"rune":   {32, true},
+	"int64":  {64, true},
+	"uint8":  {8, false},
+	"byte":   {8, false},
+	"uint16": {16, false},
+	"uint32": {32, false},
+	"uint64": {64, false},
+}
+
+// contains reports whether the integer value v lies in the range of t.
+func (t sizedInt) contains(v constant.Value) bool {
+	one := constant.MakeInt64(1)
+	lo := constant.MakeInt64(0)
+	hi := constant.Shift(one, gotoken.SHL, t.bits) // exclusive
+	if t.signed {
+		hi = constant.Shift(one, gotoken.SHL, t.bits-1)
+		lo = constant.UnaryOp(gotoken.SUB, hi, 0)
+	}
+	return constant.Compare(v, gotoken.GEQ, lo) && constant.Compare(v, gotoken.LSS, hi)
+}
+
+// intLitValue returns the value of x if x is a (possibly parenthesized)
+// integer or rune literal, or such a literal with a unary + or - operator
+// applied; otherwise it returns nil.
+func intLitValue(x Expr) constant.Value {
+	switch x := Unparen(x).(type) {
+	case *BasicLit:
+		var tok gotoken.Token
+		switch x.Kind {
+		case IntLit:
+			tok = gotoken.INT
+		case RuneLit:
+			tok = gotoken.CHAR
+		default:
+			return nil
+		}
+		if x.Bad {
+			return nil
+		}
+		if v := constant.MakeFromLiteral(x.Value, tok, 0); v.Kind() == constant.Int {
+			return v
+		}
+	case *Operation:
+		if x.Y == nil && (x.Op == Add || x.Op == Sub) {
+			if v := intLitValue(x.X); v != nil {
+				return constant.UnaryOp(opToken[x.Op], v, 0)
+			}
+		}
+	}
+	return nil
+}
+
+// MagicNumbers returns the numeric literals in the tree rooted at root
+// which are not part of a constant declaration, in source order. The
+// literals denoting the values 0 and 1 (including 0.0, 0x1, and the
+// operand of -1) are not reported.
+func MagicNumbers(root Node) []*BasicLit {
+	var list []*BasicLit
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *ConstDecl:
+			return false
+		case *BasicLit:
+			if !n.Bad && (n.Kind == IntLit || n.Kind == FloatLit || n.Kind == ImagLit) && !isZeroOrOne(n) {
+				list = append(list, n)
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// isZeroOrOne reports whether the numeric literal x denotes 0 or 1.
+func isZeroOrOne(x *BasicLit) bool {
+	var tok gotoken.Token
+	switch x.Kind {
+	case IntLit:
+		tok = gotoken.INT
+	case FloatLit:
+		tok = gotoken.FLOAT
+	default:
+		tok = gotoken.IMAG
+	}
+	v := constant.MakeFromLiteral(x.Value, tok, 0)
+	return constant.Compare(v, gotoken.EQL, constant.MakeInt64(0)) ||
+		constant.Compare(v, gotoken.EQL, constant.MakeInt64(1))
+}
+
+// IsRecursive reports whether the function or method fn calls itself
+// directly (see RecursiveCalls).
+func IsRecursive(fn *FuncDecl) bool {
+	return len(RecursiveCalls(fn)) > 0
+}
+
+// RecursiveCalls returns the direct calls of the function or method fn
+// to itself in the body of fn, including calls in function literals, in
+// source order. A call of a function is recursive if it calls the
+// function by its name (possibly instantiated, as in f[T](x)), and the
+// name is not shadowed by a local declaration. A call of a method is
+// recursive if it calls the method by name on the receiver, as in
+// r.m(); calls on other values of the receiver type are not recognized.
+func RecursiveCalls(fn *FuncDecl) []*CallExpr {
+	if fn.Body == nil {
+		return nil
+	}
+
+	var recv *Name
+	if fn.Recv != nil {
+		recv = fn.Recv.Name
+		if recv == nil || recv.Value == "_" {
+			return nil // receiver cannot be referred to
+		}
+	}
+
+	// decls maps each identifier denoting an object to its
+	// declaring identifier, or to nil for non-local objects
+	decls := make(map[*Name]*Name)
+	r := resolver{
+		use: func(name, decl *Name) { decls[name] = decl },
+	}
+	r.resolve(fn)
+
+	var list []*CallExpr
+	Inspect(fn.Body, func(n Node) bool {
+		call, ok := n.(*CallExpr)
+		if !ok {
+			return true
+		}
+		fun := Unparen(call.Fun)
+		if x, ok := fun.(*IndexExpr); ok && recv == nil {
+			fun = Unparen(x.X) // instantiated generic function
+		}
+		switch fun := fun.(type) {
+		case *Name:
+			if decl, ok := decls[fun]; recv == nil && ok && decl == nil && fun.Value == fn.Name.Value {
+				list = append(list, call)
+			}
+		case *SelectorExpr:
+			if x, ok := Unparen(fun.X).(*Name); ok && recv != nil && decls[x] == recv && fun.Sel.Value == fn.Name.Value {
+				list = append(list, call)
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// CallFanOut returns the sorted set of names of the functions and
+// methods called in the body of fn: the name f for a call f(x) or
+// f[T](x), and the selected name m for a call x.m() or pkg.m(). Calls
+// of other expressions, such as f()() or func() {}(), are ignored.
+// If lits is set, calls in function literals in the body of fn are
+// included; otherwise they are not. Without type information, calls
+// cannot be distinguished from conversions, and the names of distinct
+// functions (such as fmt.Println and log.Println) may coincide.
+func CallFanOut(fn *FuncDecl, lits bool) []string {
+	if fn.Body == nil {
+		return nil
+	}
+	var list []string
+	Inspect(fn.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case *FuncLit:
+			return lits
+		case *CallExpr:
+			if name := calleeName(n); name != nil {
+				list = append(list, name.Value)
+			}
+		}
+		return true
+	})
+	slices.Sort(list)
+	return slices.Compact(list)
+}
+
+// calleeName returns the name by which call calls a function or
+// method: f for f(x) or f[T](x), and m for x.m() or x.m[T](); otherwise
+// it returns nil.
+func calleeName(call *CallExpr) *Name {
+	fun := Unparen(call.Fun)
+	if x, ok := fun.(*IndexExpr); ok {
+		fun = Unparen(x.X) // instantiated generic function
+	}
+	switch fun := fun.(type) {
+	case *Name:
+		return fun
+	case *SelectorExpr:
+		return fun.Sel
+	}
+	return nil
+}
+
+// RecursionGroups returns the groups of mutually recursive functions and
+// methods declared in file: the strongly connected components with two or
+// more members of the call graph whose edges lead from each function to
+// the functions it calls (see CallFanOut, including calls in function
+// literals). Calls are resolved by name only; a call of f or x.f leads to
+// all functions and methods named f declared in file. Functions which are
+// only recursive by themselves are not reported; see IsRecursive. The
+// functions of each group are in source order, and the groups are ordered
+// by their first function.
+func RecursionGroups(file *File) [][]*FuncDecl {
+	var funcs []*FuncDecl
+	byName := make(map[string][]int) // function indices by name
+	for _, d := range file.DeclList {
+		if fn, ok := d.(*FuncDecl); ok {
+			byName[fn.Name.Value] = append(byName[fn.Name.Value], len(funcs))
+			funcs = append(funcs, fn)
+		}
+	}
+	callees := make([][]int, len(funcs))
+	for i, fn := range funcs {
+		for _, name := range CallFanOut(fn, true) {
+			callees[i] = append(callees[i], byName[name]...)
+		}
+	}
+
+	// Tarjan's algorithm for strongly connected components
+	var groups [][]*FuncDecl
+	index := make([]int, len(funcs)) // visit order + 1, or 0 if unvisited
+	low := make([]int, len(funcs))
+	onStack := make([]bool, len(funcs))
+	var stack []int
+	next := 1
+	var visit func(i int)
+	visit = func(i int) {
+		index[i], low[i] = next, next
+		next++
+		stack = append(stack, i)
+		onStack[i] = true
+		for _, j := range callees[i] {
+			if index[j] == 0 {
+				visit(j)
+				low[i] = min(low[i], low[j])
+			} else if onStack[j] {
+				low[i] = min(low[i], index[j])
+			}
+		}
+		if low[i] != index[i] {
+			return
+		}
+		// i is the root of a component
+		k := len(stack) - 1
+		for stack[k] != i {
+			k--
+		}
+		if len(stack)-k > 1 {
+			slices.Sort(stack[k:])
+			var g []*FuncDecl
+			for _, j := range stack[k:] {
+				g = append(g, funcs[j])
+			}
+			groups = append(groups, g)
+		}
+		for _, j := range stack[k:] {
+			onStack[j] = false
+		}
+		stack = stack[:k]
+	}
+	for i := range funcs {
+		if index[i] == 0 {
+			visit(i)
+		}
+	}
+
+	slices.SortFunc(groups, func(a, b []*FuncDecl) int {
+		return a[0].Pos().Cmp(b[0].Pos())
+	})
+	return groups
+}
+
+// DirectErrorComparisons returns the comparisons with == or != in the
+// tree rooted at root which likely compare error values and may need to
+// use errors.Is instead, in source order. A comparison is reported if
+// neither operand is nil and one operand is an identifier named err, a
+// (possibly qualified) identifier starting with Err followed by an upper
+// case letter or digit (as in io.ErrShortWrite), or an identifier (or
+// selected name) in the list of sentinel names (such as "EOF" for io.EOF).
+func DirectErrorComparisons(root Node, sentinels ...string) []*Operation {
+	isErr := func(x Expr) bool {
+		var name string
+		switch x := Unparen(x).(type) {
+		case *Name:
+			name = x.Value
+			if name == "err" {
+				return true
+			}
+		case *SelectorExpr:
+			name = x.Sel.Value
+		default:
+			return false
+		}
+		if rest, ok := strings.CutPrefix(name, "Err"); ok && rest != "" && (unicode.IsUpper(rune(rest[0])) || unicode.IsDigit(rune(rest[0]))) {
+			return true
+		}
+		return slices.Contains(sentinels, name)
+	}
+
+	var list []*Operation
+	Inspect(root, func(n Node) bool {
+		if x, ok := n.(*Operation); ok && (x.Op == Eql || x.Op == Neq) && x.Y != nil &&
+			!isNameOf(x.X, "nil") && !isNameOf(x.Y, "nil") && (isErr(x.X) || isErr(x.Y)) {
+			list = append(list, x)
+		}
+		return true
+	})
+	return list
+}
+
+// DeferOrder returns the defer statements of the function fn in the
+// order in which the deferred calls are executed when fn returns, which
+// is the reverse of the source order in which they are registered. Defer
+// statements in function literals belong to the function literals and
+// are not included. Defer statements which are executed conditionally
+// or repeatedly (for instance, in a loop) are included once.
+func DeferOrder(fn *FuncDecl) []*CallStmt {
+	if fn.Body == nil {
+		return nil
+	}
+	var list []*CallStmt
+	Inspect(fn.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case *FuncLit:
+			return false
+		case *CallStmt:
+			if n.Tok == _Defer {
+				list = append(list, n)
+			}
+		}
+		return true
+	})
+	slices.Reverse(list)
+	return list
+}
+
+// AssignmentsTo returns the assignments in the tree rooted at root
+// which assign to a variable named name, in source order. These include
+// regular assignments, operation assignments such as x += 1, increment
+// and decrement statements (which are represented as assignments), and
+// short variable declarations, where the variable is (in case of a tuple
+// assignment) one of the assigned operands. Assignments to fields or
+// elements of the variable, as in x.f = 1 or x[i] = 1, are not included.
+// Variables are matched by name, regardless of scope.
+func AssignmentsTo(root Node, name string) []*AssignStmt {
+	var list []*AssignStmt
+	Inspect(root, func(n Node) bool {
+		if s, ok := n.(*AssignStmt); ok && slices.ContainsFunc(UnpackListExpr(s.Lhs), func(x Expr) bool {
+			return isNameOf(Unparen(x), name)
+		}) {
+			list = append(list, s)
+		}
+		return true
+	})
+	return list
+}
+
+// SelfAssignments returns the plain (=) assignments in the tree rooted
+// at root which assign an operand to itself, such as x = x or
+// a.b[i] = (a.b[i]), in source order. For a multi-assignment, the
+// statement is reported if any of its pairs is a self-assignment, as
+// the second pair in x, y = z, y. Note that self-assignments are not
+// always redundant: an operand with side effects, such as a[f()] = a[f()],
+// is evaluated twice.
+func SelfAssignments(root Node) []*AssignStmt {
+	var list []*AssignStmt
+	Inspect(root, func(n Node) bool {
+		s, ok := n.(*AssignStmt)
+		if !ok || s.Op != 0 || s.Rhs == nil {
+			return true
+		}
+		lhs, rhs := UnpackListExpr(s.Lhs), UnpackListExpr(s.Rhs)
+		if len(lhs) != len(rhs) {
+			return true // multi-valued right-hand side
+		}
+		for i := range lhs {
+			if Equal(Unparen(lhs[i]), Unparen(rhs[i])) {
+				list = append(list, s)
+				break
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// PrependPatterns returns the assignments in the tree rooted at root
+// which insert elements into a slice s by appending to a new slice, in
+// source order: prepends of the form
+//
+//	s = append([]T{x, ...}, s...)
+//
+// and insertions of the form
+//
+//	s = append(s[:i], append([]T{x, ...}, s[i:]...)...)
+//
+// The operands s and i are compared structurally (see Equal).
+func PrependPatterns(root Node) []*AssignStmt {
+	var list []*AssignStmt
+	Inspect(root, func(n Node) bool {
+		s, ok := n.(*AssignStmt)
+		if !ok || s.Op != 0 || s.Rhs == nil {
+			return true
+		}
+		if isPrepend(s.Rhs, s.Lhs, nil) || isInsertion(s.Rhs, s.Lhs) {
+			list = append(list, s)
+		}
+		return true
+	})
+	return list
+}
+
+// isPrepend reports whether x is the call append([]T{...}, s[i:]...), where
+// s[i:] is just s if i is nil.
+func isPrepend(x, s, i Expr) bool {
+	call := appendCall(x)
+	if call == nil || len(call.ArgList) != 2 || !call.HasDots {
+		return false
+	}
+	if _, ok := Unparen(call.ArgList[0]).(*CompositeLit); !ok {
+		return false
+	}
+	tail := Unparen(call.ArgList[1])
+	if i == nil {
+		return Equal(tail, Unparen(s))
+	}
+	t, ok := tail.(*SliceExpr)
+	return ok && t.Index[1] == nil && !t.Full && Equal(Unparen(t.X), Unparen(s)) && Equal(t.Index[0], i)
+}
+
+// isInsertion reports whether x is the call
+// append(s[:i], append([]T{...}, s[i:]...)...).
+func isInsertion(x, s Expr) bool {
+	call := appendCall(x)
+	if call == nil || len(call.ArgList) != 2 || !call.HasDots {
+		return false
+	}
+	head, ok := Unparen(call.ArgList[0]).(*SliceExpr)
+	if !ok || head.Index[0] != nil || head.Index[1] == nil || head.Full || !Equal(Unparen(head.X), Unparen(s)) {
+		return false
+	}
+	return isPrepend(call.ArgList[1], s, head.Index[1])
+}
+
+// appendCall returns x as a call of append, or nil.
+func appendCall(x Expr) *CallExpr {
+	call, ok := Unparen(x).(*CallExpr)
+	if !ok || !isNameOf(Unparen(call.Fun), "append") {
+		return nil
+	}
+	return call
+}
+
+// SwapPatterns returns the sequences of three consecutive statements
+// in block which swap the values of two operands a and b through a
+// temporary variable and thus could be written as a, b = b, a:
+//
+//	tmp := a
+//	a = b
+//	b = tmp
+//
+// The operands are compared structurally (see Equal). The sequences are
+// only reported if neither operand refers to tmp, and tmp is not used
+// in the statements following the sequence in block. Sequences are
+// returned in source order and do not overlap.
+func SwapPatterns(block *BlockStmt) [][]Stmt {
+	var list [][]Stmt
+	stmts := block.List
+	for i := 0; i+3 <= len(stmts); i++ {
+		tmp, a, ok := singleAssign(stmts[i], Def)
+		t, ok1 := tmp.(*Name)
+		b1, b, ok2 := singleAssign(stmts[i+1], 0)
+		b2, x, ok3 := singleAssign(stmts[i+2], 0)
+		if !ok || !ok1 || !ok2 || !ok3 || !Equal(Unparen(b1), Unparen(a)) || !Equal(Unparen(b2), Unparen(b)) ||
+			!isNameOf(Unparen(x), t.Value) || Equal(Unparen(a), Unparen(b)) {
+			continue
+		}
+		if refersTo(a, t.Value) || refersTo(b, t.Value) || slices.ContainsFunc(stmts[i+3:], func(s Stmt) bool {
+			return refersTo(s, t.Value)
+		}) {
+			continue
+		}
+		list = append(list, stmts[i:i+3])
+		i += 2
+	}
+	return list
+}
+
+// singleAssign returns the operands of s if s is an assignment
+// lhs op rhs of a single operand.
+func singleAssign(s Stmt, op Operator) (lhs, rhs Expr, ok bool) {
+	a, ok := s.(*AssignStmt)
+	if !ok || a.Op != op || a.Rhs == nil {
+		return nil, nil, false
+	}
+	if _, ok := a.Lhs.(*ListExpr); ok {
+		return nil, nil, false
+	}
+	if _, ok := a.Rhs.(*ListExpr); ok {
+		return nil, nil, false
+	}
+	return a.Lhs, a.Rhs, true
+}
+
+// refersTo reports whether the tree rooted at n contains
+// an identifier with the given name.
+func refersTo(n Node, name string) bool {
+	found := false
+	Inspect(n, func(n Node) bool {
+		if x, ok := n.(*Name); ok && x.Value == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// ConditionalIIFEs returns the calls in the tree rooted at root of
+// immediately invoked function literals which emulate a conditional
+// expression, in source order. Such a function literal has neither
+// parameters nor arguments and a single result, and its body is of
+// one of the forms
+//
+//	if cond { return a }; return b
+//	if cond { return a } else { return b }
+//
+// where a and b are single expressions.
+func ConditionalIIFEs(root Node) []*CallExpr {
+	var list []*CallExpr
+	Inspect(root, func(n Node) bool {
+		if call, ok := n.(*CallExpr); ok && isConditionalIIFE(call) {
+			list = append(list, call)
+		}
+		return true
+	})
+	return list
+}
+
+// isConditionalIIFE reports whether call is a call of a
+// function literal emulating a conditional expression.
+func isConditionalIIFE(call *CallExpr) bool {
+	lit, ok := Unparen(call.Fun).(*FuncLit)
+	if !ok || len(call.ArgList) > 0 || len(lit.Type.ParamList) > 0 || len(lit.Type.ResultList) != 1 {
+		return false
+	}
+	body := lit.Body.List
+	if len(body) == 0 {
+		return false
+	}
+	s, ok := body[0].(*IfStmt)
+	if !ok || s.Init != nil || !isSingleReturn(s.Then.List) {
+		return false
+	}
+	switch len(body) {
+	case 1:
+		els, ok := s.Else.(*BlockStmt)
+		return ok && isSingleReturn(els.List)
+	case 2:
+		return s.Else == nil && isSingleReturn(body[1:])
+	}
+	return false
+}
+
+// isSingleReturn reports whether list consists of a
+// return statement with a single result.
+func isSingleReturn(list []Stmt) bool {
+	if len(list) != 1 {
+		return false
+	}
+	r, ok := list[0].(*ReturnStmt)
+	if !ok || r.Results == nil {
+		return false
+	}
+	_, isList := r.Results.(*ListExpr)
+	return !isList
+}
+
+// MaxCallChain returns the length of the longest chain of method calls
+// in the tree rooted at root, where each call is made on the result of
+// the previous one, as in a.B().C().D(), which is a chain of length 3
+// (the call a.B() starting the chain counts). Calls which are not part
+// of a chain, such as f(g(x)), have a length of 1. The result is 0 if
+// there are no calls.
+func MaxCallChain(root Node) int {
+	longest := 0
+	Inspect(root, func(n Node) bool {
+		if call, ok := n.(*CallExpr); ok {
+			k := 0
+			for ; call != nil; k++ {
+				call = chainedCall(call)
+			}
+			longest = max(longest, k)
+		}
+		return true
+	})
+	return longest
+}
+
+// chainedCall returns the call c if call is of the form c.m(...), or nil.
+func chainedCall(call *CallExpr) *CallExpr {
+	if sel, ok := Unparen(call.Fun).(*SelectorExpr); ok {
+		c, _ := Unparen(sel.X).(*CallExpr)
+		return c
+	}
+	return nil
+}
+
+// VariadicCalls returns the calls in the tree rooted at root which pass
+// a slice as the variadic argument, as in f(x, s...), in source order.
+// Analyses depending on the number of arguments, such as checks of the
+// arguments of printf-like functions, may need to skip these calls.
+func VariadicCalls(root Node) []*CallExpr {
+	var list []*CallExpr
+	Inspect(root, func(n Node) bool {
+		if call, ok := n.(*CallExpr); ok && HasSpread(call) {
+			list = append(list, call)
+		}
+		return true
+	})
+	return list
+}
+
+// HasSpread reports whether the last argument of call is
+// followed by ..., passing a slice as the variadic argument.
+func HasSpread(call *CallExpr) bool {
+	return call.HasDots
+}
+
+// ConstantReturn reports whether all return statements of fn (outside
+// of function literals) return the same constant, and returns the
+// constant (of the first return statement) if so. Constants are literals,
+// the predeclared identifiers true, false, and nil, and unary and binary
+// operations on constants; the results of return statements returning
+// several values must all be constants. Results are compared with Equal;
+// for instance, 1 and 0x1 are different constants. The result is false if
+// fn has no body, or no return statements, or any of its return statements
+// has no results, such as a return statement in a function with named
+// results, whose values are unknown.
+func ConstantReturn(fn *FuncDecl) (Expr, bool) {
+	if fn.Body == nil {
+		return nil, false
+	}
+	var x Expr
+	ok := true
+	Inspect(fn.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case *FuncLit:
+			return false
+		case *ReturnStmt:
+			switch {
+			case n.Results == nil || !isConstList(n.Results):
+				ok = false
+			case x == nil:
+				x = n.Results
+			case !Equal(x, n.Results):
+				ok = false
+			}
+		}
+		return ok
+	})
+	if !ok || x == nil {
+		return nil, false
+	}
+	return x, true
+}
+
+// isConstList reports whether x is a constant or a list of constants.
+func isConstList(x Expr) bool {
+	if l, ok := x.(*ListExpr); ok {
+		return !slices.ContainsFunc(l.ElemList, isNotConst)
+	}
+	return isConst(x)
+}
+
+// isConst reports whether x is a constant expression,
+// without type information; see ConstantReturn.
+func isConst(x Expr) bool {
+	switch x := x.(type) {
+	case *BasicLit:
+		return true
+	case *Name:
+		return x.Value == "true" || x.Value == "false" || x.Value == "nil"
+	case *ParenExpr:
+		return isConst(x.X)
+	case *Operation:
+		if x.Op == Recv || x.Op == Mul && x.Y == nil || x.Op == And && x.Y == nil {
+			return false // receive, indirection, or address operation
+		}
+		return isConst(x.X) && (x.Y == nil || isConst(x.Y))
+	}
+	return false
+}
+
+func isNotConst(x Expr) bool { return !isConst(x) }
+
+// ConcatInPrintArgs returns the string concatenations passed directly
+// as arguments to the print functions of package fmt, such as
+//
+//	fmt.Println("x = " + s)
+//
+// in source order; these are better written using the formatting of the
+// print function, as in fmt.Println("x =", s) or fmt.Printf("x = %s\n", s).
+// Without type information, an addition is considered a concatenation if
+// one of its operands (of a sequence of additions) is a string literal.
+// Calls are recognized by their selector fmt.Print, fmt.Sprintf, etc.
+func ConcatInPrintArgs(root Node) []*Operation {
+	var list []*Operation
+	Inspect(root, func(n Node) bool {
+		call, ok := n.(*CallExpr)
+		if !ok || !isPrintCall(call) {
+			return true
+		}
+		for _, arg := range call.ArgList {
+			if x, ok := Unparen(arg).(*Operation); ok && isStringConcat(x) {
+				list = append(list, x)
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// printFuncs is the set of the print functions of package fmt.
+var printFuncs = map[string]bool{
+	"Print": true, "Printf": true, "Println": true,
+	"Sprint": true, "Sprintf": true, "Sprintln": true,
+	"Fprint": true, "Fprintf": true, "Fprintln": true,
+	"Append": true, "Appendf": true, "Appendln": true,
+	"Errorf": true,
+}
+
+// isPrintCall reports whether call is a call of a print function of package fmt.
+func isPrintCall(call *CallExpr) bool {
+	sel, ok := Unparen(call.Fun).(*SelectorExpr)
+	return ok && printFuncs[sel.Sel.Value] && isNameOf(sel.X, "fmt")
+}
+
+// isStringConcat reports whether x is a sequence of additions
+// of which at least one operand is a string literal.
+func isStringConcat(x *Operation) bool {
+	if x.Op != Add || x.Y == nil {
+		return false
+	}
+	for _, y := range []Expr{x.X, x.Y} {
+		y = Unparen(y)
+		if op, ok := y.(*Operation); ok && isStringConcat(op) || isStringLit(y) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedundantBoolTerms returns the sequences of && or || operations in the
+// tree rooted at root, such as a && b && a, which contain the same operand
+// more than once, in source order. Each sequence is represented by its
+// outermost operation; for instance, for x || y && z || x, the operation
+// (x || y && z) || x is returned. Operands are compared with EqualSemantic
+// and thus x == y and y == x are the same operand.
+func RedundantBoolTerms(root Node) []*Operation {
+	var list []*Operation
+	inner := make(map[*Operation]bool) // inner operations of sequences
+	Inspect(root, func(n Node) bool {
+		x, ok := n.(*Operation)
+		if !ok || x.Op != AndAnd && x.Op != OrOr || inner[x] {
+			return true
+		}
+		for _, op := range operations(x, x.Op, nil) {
+			inner[op] = true
+		}
+		terms := operands(x, x.Op, nil)
+		for i, t := range terms {
+			if slices.ContainsFunc(terms[:i], func(u Expr) bool { return EqualSemantic(t, u) }) {
+				list = append(list, x)
+				break
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// UnkeyedStructLits returns the composite literals in the tree rooted
+// at root which are presumably struct literals with unkeyed elements, in
+// source order: literals with at least one element which is not a
+// key-value pair, and whose type is a (possibly qualified or instantiated)
+// type name, such as T{1, 2} or pkg.T[int]{x}. Literals with an array,
+// slice, or map type, or with an elided type, are never reported. Since
+// there is no type information, literals of named types which are not
+// struct types, such as a slice type declared as type S []int, are
+// reported as well.
+func UnkeyedStructLits(root Node) []*CompositeLit {
+	var list []*CompositeLit
+	Inspect(root, func(n Node) bool {
+		if x, ok := n.(*CompositeLit); ok && isTypeName(x.Type) && slices.ContainsFunc(x.ElemList, isUnkeyed) {
+			list = append(list, x)
+		}
+		return true
+	})
+	return list
+}
+
+// isTypeName reports whether x is a possibly qualified
+// or instantiated type name.
+func isTypeName(x Expr) bool {
+	switch x := x.(type) {
+	case *Name:
+		return true
+	case *SelectorExpr:
+		_, ok := x.X.(*Name)
+		return ok
+	case *IndexExpr:
+		return isTypeName(x.X)
+	}
+	return false
+}
+
+func isUnkeyed(x Expr) bool {
+	_, ok := x.(*KeyValueExpr)
+	return !ok
+}
+
+// IsPure reports whether the evaluation of the expression x has no side
+// effects: x consists of literals, identifiers, selectors, index and slice
+// expressions, type assertions, composite literals, and operations other
+// than receive operations, whose operands are pure. Calls (including
+// conversions, which cannot be distinguished from calls), receive
+// operations, function literals, and all other expressions are considered
+// impure. Pure expressions may still panic, for instance if an index is
+// out of range or a nil pointer is dereferenced.
+func IsPure(x Expr) bool {
+	switch x := x.(type) {
+	case nil:
+		return true // absent optional operand
+	case *BasicLit, *Name:
+		return true
+	case *CompositeLit:
+		return !slices.ContainsFunc(x.ElemList, isImpure)
+	case *KeyValueExpr:
+		return IsPure(x.Key) && IsPure(x.Value)
+	case *ParenExpr:
+		return IsPure(x.X)
+	case *SelectorExpr:
+		return IsPure(x.X)
+	case *IndexExpr:
+		return IsPure(x.X) && IsPure(x.Index)
+	case *SliceExpr:
+		return IsPure(x.X) && !slices.ContainsFunc(x.Index[:], isImpure)
+	case *AssertExpr:
+		return IsPure(x.X)
+	case *Operation:
+		return x.Op != Recv && IsPure(x.X) && IsPure(x.Y)
+	case *ListExpr:
+		return !slices.ContainsFunc(x.ElemList, isImpure)
+	}
+	return false
+}
+
+func isImpure(x Expr) bool { return !IsPure(x) }
+
+// TypeAssertions returns the type assertions x.(T) in the tree rooted
+// at root, in source order. Type switch guards x.(type) are not type
+// assertions; see TypeSwitches. Use IsCommaOk to determine whether an
+// assertion may panic.
+func TypeAssertions(root Node) []*AssertExpr {
+	var list []*AssertExpr
+	Inspect(root, func(n Node) bool {
+		if x, ok := n.(*AssertExpr); ok {
+			list = append(list, x)
+		}
+		return true
+	})
+	return list
+}
+
+// TypeSwitches returns the type switch statements in the tree
+// rooted at root, in source order.
+func TypeSwitches(root Node) []*SwitchStmt {
+	var list []*SwitchStmt
+	Inspect(root, func(n Node) bool {
+		if s, ok := n.(*SwitchStmt); ok {
+			if _, ok := s.Tag.(*TypeSwitchGuard); ok {
+				list = append(list, s)
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// AssignOnlySwitches returns the expression switch statements in the
+// tree rooted at root whose case clauses (including a default clause)
+// each consist of a single assignment x = v to the same (see Equal)
+// left-hand side x, in source order. Such switches may be expressed
+// more concisely, for instance as a lookup in a table of values. Type
+// switches, switches without clauses, and clauses with an assignment
+// operation such as x += v or a short variable declaration are not
+// considered.
+func AssignOnlySwitches(root Node) []*SwitchStmt {
+	var list []*SwitchStmt
+	Inspect(root, func(n Node) bool {
+		s, ok := n.(*SwitchStmt)
+		if !ok || len(s.Body) == 0 {
+			return true
+		}
+		if _, ok := s.Tag.(*TypeSwitchGuard); ok {
+			return true
+		}
+		var lhs Expr
+		for _, c := range s.Body {
+			if len(c.Body) != 1 {
+				return true
+			}
+			a, ok := c.Body[0].(*AssignStmt)
+			if !ok || a.Op != 0 || a.Rhs == nil || lhs != nil && !Equal(a.Lhs, lhs) {
+				return true
+			}
+			lhs = a.Lhs
+		}
+		list = append(list, s)
+		return true
+	})
+	return list
+}
+
+// EmptySelects returns the select statements without cases, select {},
+// which block forever, in the tree rooted at root, in source order.
+func EmptySelects(root Node) []*SelectStmt {
+	var list []*SelectStmt
+	Inspect(root, func(n Node) bool {
+		if s, ok := n.(*SelectStmt); ok && len(s.Body) == 0 {
+			list = append(list, s)
+		}
+		return true
+	})
+	return list
+}
+
+// SelectsWithDefault returns the select statements with a default case
+// (a CommClause with a nil Comm), which never block, in the tree rooted
+// at root, in source order.
+func SelectsWithDefault(root Node) []*SelectStmt {
+	var list []*SelectStmt
+	Inspect(root, func(n Node) bool {
+		if s, ok := n.(*SelectStmt); ok {
+			for _, c := range s.Body {
+				if c.Comm == nil {
+					list = append(list, s)
+					break
+				}
+			}
+		}
+		return true
+	})
+	return list
+}
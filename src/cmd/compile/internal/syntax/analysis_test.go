@@ -0,0 +1,1053 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// nodeStrings returns the ShortForm strings of the nodes in list,
+// separated by "; ".
+func nodeStrings[N Node](list []N) string {
+	var s []string
+	for _, n := range list {
+		s = append(s, String(n))
+	}
+	return strings.Join(s, "; ")
+}
+
+func TestChannelOps(t *testing.T) {
+	const src = `package p
+
+func _(ch chan<- int, in <-chan int) {
+	ch <- 1
+	x := <-in
+	v, ok := <-in
+	select {
+	case y := <-in:
+	case <-in:
+	case ch <- 2:
+	}
+	f(<-in, x - 1)
+}
+`
+	f := mustParse(t, src, 0)
+	sends, receives := ChannelOps(f)
+	if got, want := nodeStrings(sends), "ch <- 1; ch <- 2"; got != want {
+		t.Errorf("sends: got %s, want %s", got, want)
+	}
+	if got, want := nodeStrings(receives), "<-in; <-in; <-in; <-in; <-in"; got != want {
+		t.Errorf("receives: got %s, want %s", got, want)
+	}
+}
+
+func TestStringConcatInLoops(t *testing.T) {
+	const src = `package p
+
+func f(list []string, sep string) string {
+	s := ""
+	var n int
+	var b string
+	s += "x" // not in a loop
+	for _, x := range list {
+		s += x
+		s = s + sep + x
+		n += 1
+		n = n + len(x)
+		b = x + b
+		t := b
+		t += "y"
+		func() {
+			s += x // not in a loop
+			for {
+				s += x
+			}
+		}()
+	}
+	for s += "z"; len(s) < 10; s += "." {
+	}
+	return s
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(StringConcatInLoops(funcDecl(t, f, "f")))
+	const want = `s += x; s = s + sep + x; t += "y"; s += x; s += "."`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestAppendInLoopWithoutCap(t *testing.T) {
+	const src = `package p
+
+func f(list []int) {
+	var a []int
+	b := make([]int, 0, len(list))
+	var c = make([]int, 0, 10)
+	d := make([]int, 0)
+	var e []int
+	for _, x := range list {
+		a = append(a, x)
+		b = append(b, x)
+		c = append(c, x, x)
+		d = append(d, x)
+		e = append(e, x)
+		a = append(b, x)
+		a = append(a, b...)
+		g := func() {
+			a = append(a, x) // not in a loop
+		}
+	}
+	for {
+		e = append(e, 1)
+	}
+	e = make([]int, 0, 1)
+	for {
+		e = append(e, 1)
+	}
+	a = append(a, 1) // not in a loop
+	for a = append(a, 2); len(a) < 10; a = append(a, 3) {
+	}
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(AppendInLoopWithoutCap(funcDecl(t, f, "f")))
+	const want = "a = append(a, x); d = append(d, x); e = append(e, x); a = append(a, b...); e = append(e, 1); a = append(a, 3)"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestIgnoredErrors(t *testing.T) {
+	const src = `package p
+
+func _() {
+	x, _ := f()
+	_, err := f()
+	_ = g()
+	x, _ = m[k]
+	_ = x
+	h()
+	close(ch)
+	defer h()
+	go h()
+	x, y, _ := f()
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(IgnoredErrors(f))
+	const want = "x, _ := f(); _ = g(); h(); x, y, _ := f()"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestMethodCandidates(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+type G[P any] []P
+
+func f(t T)
+func g(t *T, x int)
+func h(x int, t T)
+func i(g *G[int])
+func j(s S)
+func k()
+func (T) l(t T)
+`
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, fn := range MethodCandidates(f) {
+		got = append(got, fn.Name.Value)
+	}
+	if got, want := strings.Join(got, " "), "f g i"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestUnusedReceivers(t *testing.T) {
+	const src = `package p
+
+func (t T) a() { t.m() }
+func (t T) b() {}
+func (T) c() {}
+func (_ *T) d() {}
+func (t T) e() { { t := 0; _ = t } }
+func (t T) f() { func() { _ = t }() }
+func (t T) g()
+`
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, fn := range UnusedReceivers(f) {
+		got = append(got, fn.Name.Value)
+	}
+	if got, want := strings.Join(got, " "), "b c d e"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestInconsistentReceivers(t *testing.T) {
+	const src = `package p
+
+func (t *Tree) a()
+func (tree *Tree) b()
+func (t Tree) c()
+func (_ *Tree) d()
+func (l List[T]) a()
+func (l *List[T]) b()
+func (m M) a()
+func (M) b()
+func (x *(X)) a()
+func (y X) b()
+func f(t Tree)
+`
+	f := mustParse(t, src, 0)
+	var got []string
+	for typ, list := range InconsistentReceivers(f) {
+		var methods []string
+		for _, fn := range list {
+			methods = append(methods, fn.Recv.Name.Value+"."+fn.Name.Value)
+		}
+		got = append(got, typ+": "+strings.Join(methods, " "))
+	}
+	slices.Sort(got)
+	const want = "Tree: t.a tree.b t.c; X: x.a y.b"
+	if got := strings.Join(got, "; "); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestSuspiciousStringConversions(t *testing.T) {
+	const src = `package p
+
+var _ = []string{
+	string(65),
+	string(-1),
+	string(i + 1),
+	string((2 * n)),
+	string(len(s)),
+	string(int(r)),
+	string(1 << k),
+	string(i),
+	string(rune(i)),
+	string(byte(i)),
+	string('a' + 1),
+	string(b),
+	string("x" + s),
+	string(x << 2),
+	strconv.Itoa(i + 1),
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(SuspiciousStringConversions(f))
+	const want = "string(65); string(-1); string(i + 1); string((2 * n)); string(len(s)); string(int(r)); string(1 << k)"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestConstOverflowConversions(t *testing.T) {
+	const src = `package p
+
+var _ = []any{
+	int8(127),
+	int8(128),
+	int8(-128),
+	int8(-129),
+	uint8(-1),
+	byte(0xff),
+	byte(0x1_00),
+	(uint16)(65536),
+	int32('a'),
+	rune(-(2147483649)),
+	int64(9223372036854775807),
+	int64(9223372036854775808),
+	uint64(18446744073709551615),
+	uint64(18446744073709551616),
+	int(1 << 70),
+	int8(x),
+	int8(1.5),
+	int8(1000 - 900),
+	T(1000),
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(ConstOverflowConversions(f))
+	const want = "int8(128); int8(-129); uint8(-1); byte(0x1_00); (uint16)(65536); rune(-(2147483649)); int64(9223372036854775808); uint64(18446744073709551616)"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRedundantConversions(t *testing.T) {
+	const src = `package p
+
+type T int
+
+func _() {
+	_ = T(T(x))
+	_ = T((T(x)))
+	_ = int(T(x))
+	_ = string(string(s))
+	_ = []byte([]byte(s))
+	_ = (*T)((*T)(p))
+	_ = f(f(x))
+	_ = pkg.T(pkg.T(x))
+	_ = int(int(int(x)))
+	_ = T(T(x, y))
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(RedundantConversions(f))
+	const want = "T(T(x)); T((T(x))); string(string(s)); []byte([]byte(s)); (*T)((*T)(p)); int(int(int(x))); int(int(x))"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestMagicNumbers(t *testing.T) {
+	const src = `package p
+
+const (
+	size = 1024
+	half = size / 2
+)
+
+var buf [4096]byte
+
+func _() {
+	x := 42
+	y := x * 0 + 1 - 1.0
+	z := -1
+	w := 0x1 + 0.0 + 2.5 + 3i
+	const max = 99
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(MagicNumbers(f))
+	const want = "4096; 42; 2.5; 3i"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRecursiveCalls(t *testing.T) {
+	const src = `package p
+
+func fact(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n * fact(n-1)
+}
+
+func f() { g() }
+
+func shadow() {
+	shadow := func() {}
+	shadow()
+}
+
+func lit() {
+	func() { lit() }()
+}
+
+func gen[T any](x T) { gen[T](x); (gen)(x) }
+
+func (t *T) m() { t.m(); t.n(); m(); u.m() }
+
+func (t T) n() { func(t T) { t.n() }(t) }
+
+func (T) o() { o() }
+
+func decl()
+`
+	f := mustParse(t, src, 0)
+	want := map[string]string{
+		"fact":   "fact(n - 1)",
+		"lit":    "lit()",
+		"gen":    "gen[T](x); (gen)(x)",
+		"m":      "t.m()",
+		"f":      "",
+		"shadow": "",
+		"n":      "",
+		"o":      "",
+		"decl":   "",
+	}
+	for _, d := range f.DeclList {
+		fn := d.(*FuncDecl)
+		calls := RecursiveCalls(fn)
+		if got := nodeStrings(calls); got != want[fn.Name.Value] {
+			t.Errorf("%s: got %q, want %q", fn.Name.Value, got, want[fn.Name.Value])
+		}
+		if got := IsRecursive(fn); got != (len(calls) > 0) {
+			t.Errorf("%s: IsRecursive = %v, want %v", fn.Name.Value, got, !got)
+		}
+	}
+}
+
+func TestDirectErrorComparisons(t *testing.T) {
+	const src = `package p
+
+func _() {
+	_ = err == io.EOF
+	_ = err != nil
+	_ = nil == err
+	_ = e == os.ErrNotExist
+	_ = ErrFoo != e
+	_ = x == y
+	_ = io.Errorf == f
+	_ = e == sql.ErrNoRows
+	_ = e == context.Canceled
+	_ = e == ErrX2
+	_ = err
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(DirectErrorComparisons(f, "EOF", "Canceled"))
+	const want = "err == io.EOF; e == os.ErrNotExist; ErrFoo != e; e == sql.ErrNoRows; e == context.Canceled; e == ErrX2"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestDeferOrder(t *testing.T) {
+	const src = `package p
+
+func f() {
+	defer a()
+	go b()
+	if x {
+		defer c()
+	}
+	defer func() {
+		defer d()
+	}()
+	for {
+		defer e()
+	}
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(DeferOrder(funcDecl(t, f, "f")))
+	const want = "defer e(); defer func() {…}(); defer c(); defer a()"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestAssignmentsTo(t *testing.T) {
+	const src = `package p
+
+func _() {
+	x := 1
+	x = 2
+	a, x = f()
+	y, z := x, x
+	x += 3
+	x++
+	(x)--
+	x.f = 4
+	x[0] = 5
+	ch <- x
+	func() { x = 6 }()
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(AssignmentsTo(f, "x"))
+	const want = "x := 1; x = 2; a, x = f(); x += 3; x++; (x)--; x = 6"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestIsPure(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		want bool
+	}{
+		{"1", true},
+		{`"s"`, true},
+		{"x", true},
+		{"x.f.g", true},
+		{"a[i+1]", true},
+		{"s[i:j:k]", true},
+		{"s[:]", true},
+		{"-x * (y + 1)", true},
+		{"&x.f", true},
+		{"*p", true},
+		{"x.(T)", true},
+		{"T{a: 1, b: x}", true},
+		{"[]int{1, 2}", true},
+		{"f()", false},
+		{"int(x)", false},
+		{"x.m()", false},
+		{"a[f()]", false},
+		{"s[:f()]", false},
+		{"<-ch", false},
+		{"x + <-ch", false},
+		{"func() {}", false},
+		{"T{a: f()}", false},
+		{"[]int", false},
+	} {
+		f := mustParse(t, "package p; var _ = "+test.src, 0)
+		x := f.DeclList[0].(*VarDecl).Values
+		if got := IsPure(x); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+func TestTypeAssertions(t *testing.T) {
+	const src = `package p
+
+var v1, ok1 = x.(A)
+var v2 = x.(B)
+
+func _() {
+	v3, ok3 := x.(C)
+	v4 = (x.(D))
+	v5, ok5 = (x.(E))
+	f(x.(F))
+	a, b := x.(G), y
+	switch x.(type) {
+	case int:
+		switch y := x.(type) {
+		}
+	}
+	switch x {
+	}
+}
+`
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, x := range TypeAssertions(f) {
+		s := String(x)
+		if IsCommaOk(x, f) {
+			s += " (comma-ok)"
+		}
+		got = append(got, s)
+	}
+	const want = "x.(A) (comma-ok); x.(B); x.(C) (comma-ok); x.(D); x.(E) (comma-ok); x.(F); x.(G)"
+	if got := strings.Join(got, "; "); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	var tags []string
+	for _, s := range TypeSwitches(f) {
+		tags = append(tags, String(s.Tag))
+	}
+	if got, want := strings.Join(tags, "; "), "x.(type); y := x.(type)"; got != want {
+		t.Errorf("got type switches %s, want %s", got, want)
+	}
+}
+
+func TestSelfAssignments(t *testing.T) {
+	const src = `package p
+
+func _() {
+	x = x
+	x := x
+	x += x
+	a.b[i] = (a.b[i])
+	a.b[i] = a.b[j]
+	x, y = z, y
+	x, y = y, x
+	x, y = f()
+	*p = *p
+	*p = p
+	s[i:j] = s[i:j]
+	f(func() { y = y })
+}
+`
+	f := mustParse(t, src, 0)
+	const want = "x = x; a.b[i] = (a.b[i]); x, y = z, y; *p = *p; s[i:j] = s[i:j]; y = y"
+	if got := nodeStrings(SelfAssignments(f)); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestCallFanOut(t *testing.T) {
+	const src = `package p
+
+func f() {
+	g(1)
+	g(2)
+	fmt.Println(h[int](x), x.m())
+	(k)()
+	go run()
+	defer done()
+	f()
+	f()()
+	func() { lit() }()
+}
+
+func decl()
+`
+	f := mustParse(t, src, 0)
+	for _, test := range []struct {
+		lits bool
+		want string
+	}{
+		{false, "Println done f g h k m run"},
+		{true, "Println done f g h k lit m run"},
+	} {
+		got := strings.Join(CallFanOut(funcDecl(t, f, "f"), test.lits), " ")
+		if got != test.want {
+			t.Errorf("lits = %v: got %s, want %s", test.lits, got, test.want)
+		}
+	}
+	if got := CallFanOut(funcDecl(t, f, "decl"), true); got != nil {
+		t.Errorf("got %v for function without body, want nil", got)
+	}
+}
+
+func TestPrependPatterns(t *testing.T) {
+	const src = `package p
+
+func _() {
+	s = append([]int{x}, s...)
+	a.s = append([]T{x, y}, (a.s)...)
+	s = append(s[:i], append([]int{x}, s[i:]...)...)
+	s = append(s[:i+1], append([]int{x}, s[i+1:]...)...)
+
+	// not matched
+	t = append([]int{x}, s...)
+	s := append([]int{x}, s...)
+	s = append([]int{x}, s)
+	s = append(s, x)
+	s = append(prefix, s...)
+	s = append(s[:i], append([]int{x}, s[j:]...)...)
+	s = append(s[:i], append([]int{x}, t[i:]...)...)
+	s = append(s[:i], s[i+1:]...)
+}
+`
+	f := mustParse(t, src, 0)
+	const want = "s = append([]int{…}, s...); a.s = append([]T{…}, (a.s)...); " +
+		"s = append(s[:i], append([]int{…}, s[i:]...)...); s = append(s[:i + 1], append([]int{…}, s[i + 1:]...)...)"
+	if got := nodeStrings(PrependPatterns(f)); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestSwapPatterns(t *testing.T) {
+	const src = `package p
+
+func _() {
+	tmp := a
+	a = b
+	b = tmp
+
+	t := s[i]
+	s[i] = (s[j])
+	s[j] = t
+
+	x := p.f
+	p.f = q.f
+	q.f = x
+	x++
+
+	y := a
+	a = b
+	c = y
+
+	z := a[z]
+	a[z] = b
+	b = z
+
+	u := a
+	a = a
+	a = u
+}
+`
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, list := range SwapPatterns(funcDecl(t, f, "_").Body) {
+		got = append(got, nodeStrings(list))
+	}
+	const want = "tmp := a; a = b; b = tmp | t := s[i]; s[i] = (s[j]); s[j] = t"
+	if got := strings.Join(got, " | "); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestConditionalIIFEs(t *testing.T) {
+	const src = `package p
+
+var (
+	_ = func() int { if c { return a }; return b }()
+	_ = func() (x int) { if c { return a } else { return b } }()
+	_ = (func() string { if c { return "a" }; return "b" })()
+
+	// not matched
+	_ = func() int { if c { return a }; return b }
+	_ = func(c bool) int { if c { return a }; return b }(true)
+	_ = func() (int, int) { if c { return a, b }; return b, a }()
+	_ = func() int { if c := f(); c { return a }; return b }()
+	_ = func() int { if c { return a } else if d { return b }; return 0 }()
+	_ = func() int { if c { g(); return a }; return b }()
+	_ = func() int { x := a; if c { return x }; return b }()
+	_ = func() int { return a }()
+)
+`
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, call := range ConditionalIIFEs(f) {
+		got = append(got, lineString(call))
+	}
+	want := []string{
+		"func() int { if c { return a }; return b }()",
+		"func() (x int) { if c { return a } else { return b } }()",
+		`(func() string { if c { return "a" }; return "b" })()`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMaxCallChain(t *testing.T) {
+	for _, test := range []struct {
+		x    string
+		want int
+	}{
+		{"x", 0},
+		{"f()", 1},
+		{"f(g(h()))", 1},
+		{"a.B()", 1},
+		{"a.B().C()", 2},
+		{"a.B().C().D().E()", 4},
+		{"(a.B()).C()", 2},
+		{"a.B().c.D()", 1},
+		{"f().M()", 2},
+		{"g(a.B().C(), x.Y().Z().W())", 3},
+		{"func() { a.B().C() }", 2},
+	} {
+		f := mustParse(t, "package p; var _ = "+test.x, 0)
+		if got := MaxCallChain(f); got != test.want {
+			t.Errorf("%s: got %d, want %d", test.x, got, test.want)
+		}
+	}
+}
+
+func TestConcatInPrintArgs(t *testing.T) {
+	const src = `package p
+
+func f() {
+	fmt.Println("x = " + x)
+	fmt.Printf("%s\n", a+"b"+c, (d + "e"))
+	fmt.Println(a + b)
+	fmt.Fprintln(w, "a"+("b"+c))
+	log.Println("x = " + x)
+	s := fmt.Sprint(g("a" + b))
+	fmt.Println(-"a")
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(ConcatInPrintArgs(f))
+	const want = `"x = " + x; a + "b" + c; d + "e"; "a" + ("b" + c)`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestUnkeyedStructLits(t *testing.T) {
+	const src = `package p
+
+var _ = []any{
+	T{1, 2},
+	T{a: 1, b: 2},
+	T{},
+	&pkg.T{x},
+	G[int]{1, b: 2},
+	[]int{1, 2},
+	[2]T{{1, 2}, {}},
+	map[string]T{"k": {1}},
+	struct{ a int }{1},
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(UnkeyedStructLits(f))
+	const want = "T{…}; pkg.T{…}; G[int]{…}"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestConstantReturn(t *testing.T) {
+	const src = `package p
+
+func a(x int) int {
+	if x > 0 {
+		return 1
+	}
+	f := func() int { return 2 }
+	return 1
+}
+
+func b(x int) bool {
+	switch x {
+	case 0:
+		return false
+	}
+	panic(x)
+	return false
+}
+
+func c() (int, error) {
+	if x {
+		return -1, nil
+	}
+	return -1, nil
+}
+
+func d(x int) int {
+	if x > 0 {
+		return 1
+	}
+	return 2
+}
+
+func e() (r int) {
+	return
+}
+
+func f(x int) int { return x }
+func g() string   { return "a" + "b" }
+func h() *int     { return nil }
+func i() int      { return 1; return 0x1 }
+func j()          {}
+func k() int
+`
+	f := mustParse(t, src, 0)
+	want := map[string]string{
+		"a": "1",
+		"b": "false",
+		"c": "-1, nil",
+		"g": `"a" + "b"`,
+		"h": "nil",
+	}
+	for _, d := range f.DeclList {
+		fn := d.(*FuncDecl)
+		x, ok := ConstantReturn(fn)
+		got := ""
+		if ok {
+			got = String(x)
+		}
+		if got != want[fn.Name.Value] {
+			t.Errorf("%s: got %q (%v), want %q", fn.Name.Value, got, ok, want[fn.Name.Value])
+		}
+	}
+}
+
+func TestRecursionGroups(t *testing.T) {
+	const src = `package p
+
+func a() { b() }
+func b() { c(); d() }
+func c() { a() }
+func d() { d() }
+func e() { go func() { f() }() }
+func (T) f() { e() }
+func g() { a(); h() }
+func h() {}
+func even(n int) bool { return n == 0 || odd(n-1) }
+func odd(n int) bool { return n != 0 && even(n-1) }
+`
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, g := range RecursionGroups(f) {
+		var names []string
+		for _, fn := range g {
+			names = append(names, fn.Name.Value)
+		}
+		got = append(got, strings.Join(names, " "))
+	}
+	const want = "a b c; e f; even odd"
+	if got := strings.Join(got, "; "); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestVariadicCalls(t *testing.T) {
+	const src = `package p
+
+func f(args ...any) {
+	g(args...)
+	fmt.Printf("%d %s", 1, "x")
+	fmt.Printf(format, h(args...)...)
+	_ = append(s, t...)
+	_ = append(s, 1, 2)
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(VariadicCalls(f))
+	const want = "g(args...); fmt.Printf(format, h(args...)...); h(args...); append(s, t...)"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRedundantBoolTerms(t *testing.T) {
+	const src = `package p
+
+var _ = []bool{
+	a && b && a,
+	x || y || x,
+	a && b && c,
+	x || y && z || x,
+	a == b && c && b == a,
+	a && (b || c || b),
+	f(x && x),
+	a && b || a && b,
+	a && (a),
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(RedundantBoolTerms(f))
+	const want = "a && b && a; x || y || x; x || y && z || x; a == b && c && b == a; b || c || b; x && x; a && b || a && b"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestAssignOnlySwitches(t *testing.T) {
+	const src = `package p
+
+func _() {
+	switch x {
+	case 1:
+		s = "one"
+	case 2, 3:
+		s = "few"
+	default:
+		s = "many"
+	}
+	switch {
+	case a:
+		m[k] = 1
+	case b:
+		m[k] = 2
+	}
+	switch x {
+	case 1:
+		s = "one"
+	case 2:
+		t = "two"
+	}
+	switch x {
+	case 1:
+		s = "one"
+	case 2:
+		s += "two"
+	}
+	switch x {
+	case 1:
+		s = "one"
+	case 2:
+		f()
+		s = "two"
+	}
+	switch x {
+	case 1:
+		s = "one"
+	case 2:
+	}
+	switch v := x.(type) {
+	case int:
+		s = v
+	}
+	switch x {
+	}
+}
+`
+	f := mustParse(t, src, 0)
+	var lines []string
+	for _, s := range AssignOnlySwitches(f) {
+		lines = append(lines, fmt.Sprint(s.Pos().Line()))
+	}
+	if got, want := strings.Join(lines, " "), "4 12"; got != want {
+		t.Errorf("got switches on lines %s, want %s", got, want)
+	}
+}
+
+func TestTimeAfterInLoops(t *testing.T) {
+	const src = `package p
+
+func _() {
+	<-time.After(a)
+	for {
+		select {
+		case v := <-ch:
+			_ = v
+		case <-time.After(b):
+			return
+		}
+	}
+	for i := range time.After(c) {
+		f(time.After(d))
+		go func() { <-time.After(e) }()
+		_ = func() {
+			for {
+				<-(time.After)(g)
+			}
+		}
+	}
+	for x := <-time.After(h); ; {
+		t.After(i)
+	}
+	for ok := true; ok && wait(time.After(j)); ok = wait(time.After(k)) {
+	}
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(TimeAfterInLoops(funcDecl(t, f, "_")))
+	const want = "time.After(b); time.After(d); (time.After)(g); time.After(j); time.After(k)"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestSelects(t *testing.T) {
+	const src = `package p
+
+func _() {
+	select {}
+	select {
+	case <-c:
+	default:
+		select {
+		}
+	}
+	select {
+	case v := <-c:
+		_ = v
+	case c <- 1:
+	}
+	go func() {
+		select {
+		default:
+		}
+	}()
+}
+`
+	f := mustParse(t, src, 0)
+	lines := func(list []*SelectStmt) string {
+		var s []string
+		for _, x := range list {
+			s = append(s, fmt.Sprint(x.Pos().Line()))
+		}
+		return strings.Join(s, " ")
+	}
+	if got, want := lines(EmptySelects(f)), "4 8"; got != want {
+		t.Errorf("got empty selects on lines %s, want %s", got, want)
+	}
+	if got, want := lines(SelectsWithDefault(f)), "5 17"; got != want {
+		t.Errorf("got selects with default on lines %s, want %s", got, want)
+	}
+}
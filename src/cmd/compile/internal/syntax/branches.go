@@ -4,7 +4,10 @@
 
 package syntax
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
 // checkBranches checks correct use of labels and branch
 // statements (break, continue, fallthrough, goto) in a function body.
@@ -20,6 +23,11 @@ func checkBranches(body *BlockStmt, errh ErrorHandler) {
 
 	// scope of all labels in this body
 	ls := &labelScope{errh: errh}
+	ls.checkBody(body)
+}
+
+// checkBody checks the function body with the label scope ls.
+func (ls *labelScope) checkBody(body *BlockStmt) {
 	fwdGotos := ls.blockBranches(nil, targets{}, nil, body.Pos(), body.List)
 
 	// If there are any forward gotos left, no matching label was
@@ -29,6 +37,7 @@ func checkBranches(body *BlockStmt, errh ErrorHandler) {
 		name := fwd.Label.Value
 		if l := ls.labels[name]; l != nil {
 			l.used = true // avoid "defined and not used" error
+			ls.illegal(fwd)
 			ls.errf(fwd.Label.Pos(), "goto %s jumps into block starting at %s", name, l.parent.start)
 		} else {
 			ls.errf(fwd.Label.Pos(), "label %s not defined", name)
@@ -47,6 +56,10 @@ func checkBranches(body *BlockStmt, errh ErrorHandler) {
 type labelScope struct {
 	errh   ErrorHandler
 	labels map[string]*label // all label declarations inside the function; allocated lazily
+
+	// illegalGoto, if set, is called for each goto jumping
+	// over a variable declaration or into a block
+	illegalGoto func(s *BranchStmt)
 }
 
 type label struct {
@@ -61,6 +74,12 @@ type block struct {
 	lstmt  *LabeledStmt // labeled statement associated with this block, or nil
 }
 
+func (ls *labelScope) illegal(s *BranchStmt) {
+	if ls.illegalGoto != nil {
+		ls.illegalGoto(s)
+	}
+}
+
 func (ls *labelScope) errf(pos Pos, format string, args ...interface{}) {
 	ls.errh(Error{pos, fmt.Sprintf(format, args...)})
 }
@@ -188,6 +207,7 @@ func (ls *labelScope) blockBranches(parent *block, ctxt targets, lstmt *LabeledS
 						fwd.Target = s
 						l.used = true
 						if jumpsOverVarDecl(fwd) {
+							ls.illegal(fwd)
 							ls.errf(
 								fwd.Label.Pos(),
 								"goto %s jumps over declaration of %s at %s",
@@ -337,3 +357,49 @@ func trimTrailingEmptyStmts(list []Stmt) []Stmt {
 	}
 	return nil
 }
+
+// IllegalGotos returns the goto statements in the body of fn, including
+// function literals, which jump over a variable declaration into its
+// scope, or into a block from outside the block, in source order. Both
+// are invalid in Go.
+// Unlike the parser in CheckBranches mode, IllegalGotos does not set the
+// targets of branch statements.
+func IllegalGotos(fn *FuncDecl) []*BranchStmt {
+	if fn.Body == nil {
+		return nil
+	}
+
+	// checkBody sets the branch statement targets; restore them afterwards
+	targets := make(map[*BranchStmt]Stmt)
+	Inspect(fn.Body, func(n Node) bool {
+		if s, ok := n.(*BranchStmt); ok {
+			targets[s] = s.Target
+		}
+		return true
+	})
+	defer func() {
+		for s, t := range targets {
+			s.Target = t
+		}
+	}()
+
+	var list []*BranchStmt
+	check := func(body *BlockStmt) {
+		ls := &labelScope{
+			errh:        func(error) {},
+			illegalGoto: func(s *BranchStmt) { list = append(list, s) },
+		}
+		ls.checkBody(body)
+	}
+	check(fn.Body)
+	Inspect(fn.Body, func(n Node) bool {
+		if f, ok := n.(*FuncLit); ok {
+			check(f.Body) // function literals have their own labels
+		}
+		return true
+	})
+	slices.SortFunc(list, func(a, b *BranchStmt) int {
+		return a.Pos().Cmp(b.Pos())
+	})
+	return list
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestIllegalGotos(t *testing.T) {
+	const src = `package p
+
+func f() {
+	goto A // jumps over x
+	x := 0
+A:
+	_ = x
+
+	goto B // jumps into block
+	{
+	B:
+	}
+
+	goto C // ok: jumps out of block with declaration
+	{
+		var y int
+		_ = y
+	}
+C:
+
+	goto D // jumps over z
+	var z int
+D:
+	_ = z
+
+	func() {
+		goto E // jumps over w
+		w := 0
+	E:
+		_ = w
+	}()
+
+F:
+	v := 0
+	_ = v
+	goto F // ok: backward jump
+}
+`
+	f := mustParse(t, src, 0)
+	fn := funcDecl(t, f, "f")
+	var got []string
+	for _, s := range IllegalGotos(fn) {
+		got = append(got, fmt.Sprintf("%d: %s", s.Pos().Line(), String(s)))
+	}
+	const want = "4: goto A, 9: goto B, 21: goto D, 27: goto E"
+	if s := strings.Join(got, ", "); s != want {
+		t.Errorf("got  %s\nwant %s", s, want)
+	}
+
+	// targets are not set
+	Inspect(fn, func(n Node) bool {
+		if s, ok := n.(*BranchStmt); ok && s.Target != nil {
+			t.Errorf("%s: target set", String(s))
+		}
+		return true
+	})
+}
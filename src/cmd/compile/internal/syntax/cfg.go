@@ -0,0 +1,369 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the construction of control-flow graphs.
+
+package syntax
+
+import "fmt"
+
+// A CFG is the control-flow graph of a function body. Its blocks
+// are basic blocks: maximal sequences of nodes which are executed
+// in order, without control flow into or out of the sequence
+// except at its beginning and end.
+type CFG struct {
+	Blocks []*Block // Blocks[0] is the entry block
+}
+
+// A Block is a basic block of a CFG.
+type Block struct {
+	Index int    // index of the block in CFG.Blocks
+	Kind  string // kind of the block, such as "entry", "if.then", or "for.body"
+
+	// Nodes holds the nodes executed in the block, in order: simple
+	// statements, return statements, and the expressions evaluated
+	// for control flow decisions (conditions, switch tags and case
+	// expressions, range clauses, and select communications). The
+	// control flow decision at the end of a block, if any, is made
+	// based on its last node.
+	Nodes []Node
+
+	// Succs holds the successors of the block. A block ending in a
+	// condition has two successors: the successor if the condition is
+	// true, followed by the one if it is false. A block with no
+	// successors ends in a return statement, a call of panic, or the
+	// end of the function body.
+	Succs []*Block
+	Preds []*Block // predecessors of the block
+}
+
+func (b *Block) String() string {
+	return fmt.Sprintf("%d (%s)", b.Index, b.Kind)
+}
+
+// BuildCFG returns the control-flow graph of the body of fn, or nil
+// if fn has no body. Statements which cannot be reached, such as the
+// statements following a return statement, are placed in blocks of
+// kind "unreachable" which have no predecessors. Function literals
+// are not entered; they are nodes of the blocks they appear in. Calls
+// of panic are assumed to refer to the predeclared function and thus
+// not to return.
+func BuildCFG(fn *FuncDecl) *CFG {
+	if fn.Body == nil {
+		return nil
+	}
+	b := cfgBuilder{
+		cfg:    new(CFG),
+		labels: make(map[string]*labelBlocks),
+	}
+	b.current = b.newBlock("entry")
+	b.stmtList(fn.Body.List)
+	for _, blk := range b.cfg.Blocks {
+		for _, s := range blk.Succs {
+			s.Preds = append(s.Preds, blk)
+		}
+	}
+	return b.cfg
+}
+
+type cfgBuilder struct {
+	cfg     *CFG
+	current *Block                  // block to which nodes are added
+	labels  map[string]*labelBlocks // blocks of labeled statements
+	targets *branchTargets          // innermost targets of unlabeled branches
+}
+
+// labelBlocks holds the branch targets of a labeled statement.
+type labelBlocks struct {
+	_goto     *Block // beginning of the labeled statement
+	_break    *Block // for labeled for, switch, and select statements
+	_continue *Block // for labeled for statements
+}
+
+// branchTargets holds the targets of unlabeled branch statements
+// in the innermost enclosing statements.
+type branchTargets struct {
+	outer        *branchTargets
+	_break       *Block
+	_continue    *Block
+	_fallthrough *Block
+}
+
+func (b *cfgBuilder) newBlock(kind string) *Block {
+	blk := &Block{Index: len(b.cfg.Blocks), Kind: kind}
+	b.cfg.Blocks = append(b.cfg.Blocks, blk)
+	return blk
+}
+
+func (b *cfgBuilder) add(n Node) {
+	b.current.Nodes = append(b.current.Nodes, n)
+}
+
+// jump adds an edge from the current block to target.
+func (b *cfgBuilder) jump(target *Block) {
+	b.current.Succs = append(b.current.Succs, target)
+}
+
+// branch adds edges from the current block, which ends in a
+// condition, to the blocks t and f.
+func (b *cfgBuilder) branch(t, f *Block) {
+	b.current.Succs = append(b.current.Succs, t, f)
+}
+
+// label returns the blocks of the statement labeled with name.
+func (b *cfgBuilder) label(name *Name) *labelBlocks {
+	lb := b.labels[name.Value]
+	if lb == nil {
+		lb = &labelBlocks{_goto: b.newBlock("label." + name.Value)}
+		b.labels[name.Value] = lb
+	}
+	return lb
+}
+
+func (b *cfgBuilder) stmtList(list []Stmt) {
+	for _, s := range list {
+		b.stmt(s, nil)
+	}
+}
+
+// stmt adds the statement s, labeled with the given label
+// (or nil), to the graph.
+func (b *cfgBuilder) stmt(s Stmt, label *labelBlocks) {
+	switch s := s.(type) {
+	case *EmptyStmt:
+		// nothing to do
+
+	case *DeclStmt, *SendStmt, *AssignStmt, *CallStmt:
+		b.add(s)
+
+	case *ExprStmt:
+		b.add(s)
+		if call, ok := Unparen(s.X).(*CallExpr); ok && isNameOf(Unparen(call.Fun), "panic") {
+			b.current = b.newBlock("unreachable")
+		}
+
+	case *ReturnStmt:
+		b.add(s)
+		b.current = b.newBlock("unreachable")
+
+	case *BlockStmt:
+		b.stmtList(s.List)
+
+	case *LabeledStmt:
+		lb := b.label(s.Label)
+		b.jump(lb._goto)
+		b.current = lb._goto
+		b.stmt(s.Stmt, lb)
+
+	case *BranchStmt:
+		b.branchStmt(s)
+
+	case *IfStmt:
+		if s.Init != nil {
+			b.stmt(s.Init, nil)
+		}
+		then := b.newBlock("if.then")
+		done := b.newBlock("if.done")
+		els := done
+		if s.Else != nil {
+			els = b.newBlock("if.else")
+		}
+		b.add(s.Cond)
+		b.branch(then, els)
+		b.current = then
+		b.stmt(s.Then, nil)
+		b.jump(done)
+		if s.Else != nil {
+			b.current = els
+			b.stmt(s.Else, nil)
+			b.jump(done)
+		}
+		b.current = done
+
+	case *SwitchStmt:
+		b.switchStmt(s, label)
+
+	case *SelectStmt:
+		b.selectStmt(s, label)
+
+	case *ForStmt:
+		if rc, ok := s.Init.(*RangeClause); ok {
+			b.rangeStmt(s, rc, label)
+		} else {
+			b.forStmt(s, label)
+		}
+
+	default:
+		panic(fmt.Sprintf("%s: unexpected statement %T", s.Pos(), s))
+	}
+}
+
+func (b *cfgBuilder) branchStmt(s *BranchStmt) {
+	var target *Block
+	switch s.Tok {
+	case _Break:
+		if s.Label != nil {
+			target = b.label(s.Label)._break
+		} else {
+			for t := b.targets; t != nil && target == nil; t = t.outer {
+				target = t._break
+			}
+		}
+	case _Continue:
+		if s.Label != nil {
+			target = b.label(s.Label)._continue
+		} else {
+			for t := b.targets; t != nil && target == nil; t = t.outer {
+				target = t._continue
+			}
+		}
+	case _Fallthrough:
+		if b.targets != nil {
+			target = b.targets._fallthrough
+		}
+	case _Goto:
+		target = b.label(s.Label)._goto
+	}
+	if target != nil { // nil for invalid branches
+		b.jump(target)
+	}
+	b.current = b.newBlock("unreachable")
+}
+
+func (b *cfgBuilder) switchStmt(s *SwitchStmt, label *labelBlocks) {
+	if s.Init != nil {
+		b.stmt(s.Init, nil)
+	}
+	if s.Tag != nil {
+		b.add(s.Tag)
+	}
+	done := b.newBlock("switch.done")
+	if label != nil {
+		label._break = done
+	}
+
+	// Each case clause with case expressions is preceded by tests of
+	// the expressions; the default clause is entered if all tests fail.
+	var defaultClause *CaseClause
+	var defaultBody, defaultNext *Block
+	var body *Block // body of the current clause
+	for i, cc := range s.Body {
+		if body == nil {
+			body = b.newBlock("switch.body")
+		}
+		next := done // body of the next clause, for fallthrough
+		if i+1 < len(s.Body) {
+			next = b.newBlock("switch.body")
+		}
+		if cc.Cases == nil {
+			defaultClause, defaultBody, defaultNext = cc, body, next
+			body = next
+			continue
+		}
+		var test *Block
+		for _, x := range UnpackListExpr(cc.Cases) {
+			test = b.newBlock("switch.next")
+			b.add(x)
+			b.branch(body, test)
+			b.current = test
+		}
+		b.current = body
+		b.targets = &branchTargets{outer: b.targets, _break: done, _fallthrough: next}
+		b.stmtList(cc.Body)
+		b.targets = b.targets.outer
+		b.jump(done)
+		b.current = test
+		body = next
+	}
+	if defaultClause != nil {
+		b.jump(defaultBody)
+		b.current = defaultBody
+		b.targets = &branchTargets{outer: b.targets, _break: done, _fallthrough: defaultNext}
+		b.stmtList(defaultClause.Body)
+		b.targets = b.targets.outer
+	}
+	b.jump(done)
+	b.current = done
+}
+
+func (b *cfgBuilder) selectStmt(s *SelectStmt, label *labelBlocks) {
+	done := b.newBlock("select.done")
+	if label != nil {
+		label._break = done
+	}
+	head := b.current
+	for _, cc := range s.Body {
+		body := b.newBlock("select.body")
+		head.Succs = append(head.Succs, body)
+		b.current = body
+		if cc.Comm != nil {
+			b.add(cc.Comm)
+		}
+		b.targets = &branchTargets{outer: b.targets, _break: done}
+		b.stmtList(cc.Body)
+		b.targets = b.targets.outer
+		b.jump(done)
+	}
+	b.current = done
+}
+
+func (b *cfgBuilder) forStmt(s *ForStmt, label *labelBlocks) {
+	if s.Init != nil {
+		b.stmt(s.Init, nil)
+	}
+	loop := b.newBlock("for.loop")
+	body := b.newBlock("for.body")
+	done := b.newBlock("for.done")
+	cont := loop
+	if s.Post != nil {
+		cont = b.newBlock("for.post")
+	}
+	if label != nil {
+		label._break, label._continue = done, cont
+	}
+
+	b.jump(loop)
+	b.current = loop
+	if s.Cond != nil {
+		b.add(s.Cond)
+		b.branch(body, done)
+	} else {
+		b.jump(body)
+	}
+
+	b.current = body
+	b.targets = &branchTargets{outer: b.targets, _break: done, _continue: cont}
+	b.stmt(s.Body, nil)
+	b.targets = b.targets.outer
+	b.jump(cont)
+
+	if s.Post != nil {
+		b.current = cont
+		b.stmt(s.Post, nil)
+		b.jump(loop)
+	}
+	b.current = done
+}
+
+func (b *cfgBuilder) rangeStmt(s *ForStmt, rc *RangeClause, label *labelBlocks) {
+	b.add(rc.X)
+	loop := b.newBlock("range.loop")
+	body := b.newBlock("range.body")
+	done := b.newBlock("range.done")
+	if label != nil {
+		label._break, label._continue = done, loop
+	}
+
+	b.jump(loop)
+	b.current = loop
+	b.add(rc) // assignment of the next iteration values, if any
+	b.branch(body, done)
+
+	b.current = body
+	b.targets = &branchTargets{outer: b.targets, _break: done, _continue: loop}
+	b.stmt(s.Body, nil)
+	b.targets = b.targets.outer
+	b.jump(loop)
+	b.current = done
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// cfgString returns a description of g, with one line per block:
+// its index and kind, its nodes, and the indices of its successors.
+func cfgString(g *CFG) string {
+	var buf strings.Builder
+	for _, b := range g.Blocks {
+		fmt.Fprintf(&buf, "%s:", b)
+		for _, n := range b.Nodes {
+			fmt.Fprintf(&buf, " [%s]", lineString(n))
+		}
+		if len(b.Succs) > 0 {
+			buf.WriteString(" ->")
+			for _, s := range b.Succs {
+				fmt.Fprintf(&buf, " %d", s.Index)
+			}
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+func TestBuildCFG(t *testing.T) {
+	for _, test := range []struct {
+		body, want string
+	}{
+		{"", `
+0 (entry):
+`},
+		{"a(); return; b()", `
+0 (entry): [a()] [return]
+1 (unreachable): [b()]
+`},
+		{"if x { a() } else { b() }; c()", `
+0 (entry): [x] -> 1 3
+1 (if.then): [a()] -> 2
+2 (if.done): [c()]
+3 (if.else): [b()] -> 2
+`},
+		{"for i := 0; i < n; i++ { if c { continue }; a() }", `
+0 (entry): [i := 0] -> 1
+1 (for.loop): [i < n] -> 2 3
+2 (for.body): [c] -> 5 6
+3 (for.done):
+4 (for.post): [i++] -> 1
+5 (if.then): -> 4
+6 (if.done): [a()] -> 4
+7 (unreachable): -> 6
+`},
+		{"for _, x := range s { if x { break } }", `
+0 (entry): [s] -> 1
+1 (range.loop): [_, x := range s] -> 2 3
+2 (range.body): [x] -> 4 5
+3 (range.done):
+4 (if.then): -> 3
+5 (if.done): -> 1
+6 (unreachable): -> 5
+`},
+		{"switch x { case 1, 2: a(); fallthrough; default: b(); case 3: }", `
+0 (entry): [x] [1] -> 2 4
+1 (switch.done):
+2 (switch.body): [a()] -> 3
+3 (switch.body): [b()] -> 1
+4 (switch.next): [2] -> 2 5
+5 (switch.next): [3] -> 7 8
+6 (unreachable): -> 1
+7 (switch.body): -> 1
+8 (switch.next): -> 3
+`},
+		{"L: for { select { case <-c: break L; case x := <-d: continue L } }", `
+0 (entry): -> 1
+1 (label.L): -> 2
+2 (for.loop): -> 3
+3 (for.body): -> 6 8
+4 (for.done):
+5 (select.done): -> 2
+6 (select.body): [<-c] -> 4
+7 (unreachable): -> 5
+8 (select.body): [x := <-d] -> 2
+9 (unreachable): -> 5
+`},
+		{"goto L; a(); L: panic(x); b()", `
+0 (entry): -> 1
+1 (label.L): [panic(x)]
+2 (unreachable): [a()] -> 1
+3 (unreachable): [b()]
+`},
+	} {
+		f := mustParse(t, "package p; func f() { "+test.body+" }", 0)
+		got := cfgString(BuildCFG(funcDecl(t, f, "f")))
+		if want := test.want[1:]; got != want {
+			t.Errorf("%s:\ngot\n%swant\n%s", test.body, got, want)
+		}
+	}
+
+	// predecessors are consistent with successors
+	f := mustParse(t, "package p; func f() { for c { if x { a() } } }", 0)
+	for _, b := range BuildCFG(funcDecl(t, f, "f")).Blocks {
+		for _, s := range b.Succs {
+			if !slices.Contains(s.Preds, b) {
+				t.Errorf("%s is not a predecessor of %s", b, s)
+			}
+		}
+	}
+}
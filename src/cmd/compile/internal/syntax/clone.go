@@ -0,0 +1,102 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements deep copying of syntax trees.
+
+package syntax
+
+import "reflect"
+
+// Clone returns a deep copy of the syntax tree rooted at n.
+// Nodes shared within the tree (such as the type of fields
+// declared in a group) are shared within the copy as well, and
+// declarations of the same group belong to the same new group.
+// Branch statement targets and other references to nodes inside
+// the tree refer to the respective copies; references to nodes
+// outside the tree are retained. Positions, pragmas, and type
+// information are copied as is.
+func Clone[N Node](n N) N {
+	c := cloner{memo: make(map[any]reflect.Value)}
+	x := c.value(reflect.ValueOf(n)).Interface().(N)
+	for _, b := range c.branches {
+		if t, ok := c.memo[b.Target]; ok {
+			b.Target = t.Interface().(Stmt)
+		}
+	}
+	return x
+}
+
+type cloner struct {
+	memo     map[any]reflect.Value // original node -> copy
+	branches []*BranchStmt         // copied branch statements with targets
+}
+
+var (
+	nodeType  = reflect.TypeFor[Node]()
+	groupType = reflect.TypeFor[*Group]()
+)
+
+// value returns a deep copy of v, which is a (possibly nil)
+// node or a value containing nodes.
+func (c *cloner) value(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || !v.Type().Implements(nodeType) && v.Type() != groupType {
+			return v
+		}
+		key := v.Interface()
+		if x, ok := c.memo[key]; ok {
+			return x
+		}
+		x := reflect.New(v.Type().Elem())
+		c.memo[key] = x
+		x.Elem().Set(v.Elem()) // shallow copy, incl. position and type info
+		c.fields(x.Elem())
+		if b, ok := x.Interface().(*BranchStmt); ok && b.Target != nil {
+			c.branches = append(c.branches, b)
+		}
+		return x
+
+	case reflect.Interface:
+		if v.IsNil() || !v.Elem().Type().Implements(nodeType) {
+			return v // not a node (e.g., a Pragma)
+		}
+		x := reflect.New(v.Type()).Elem()
+		x.Set(c.value(v.Elem()))
+		return x
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		x := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			x.Index(i).Set(c.value(v.Index(i)))
+		}
+		return x
+
+	case reflect.Array:
+		x := reflect.New(v.Type()).Elem()
+		for i := range v.Len() {
+			x.Index(i).Set(c.value(v.Index(i)))
+		}
+		return x
+	}
+
+	return v
+}
+
+// fields replaces the exported fields of the node struct x, which
+// hold the children and other node references, with deep copies.
+// Branch statement targets are adjusted by Clone at the end.
+func (c *cloner) fields(x reflect.Value) {
+	t := x.Type()
+	for i := range t.NumField() {
+		if f := t.Field(i); f.IsExported() && !(t == branchStmtType && f.Name == "Target") {
+			x.Field(i).Set(c.value(x.Field(i)))
+		}
+	}
+}
+
+var branchStmtType = reflect.TypeFor[BranchStmt]()
@@ -0,0 +1,99 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	const src = `package p
+
+import "fmt"
+
+var (
+	a, b int
+	c = 1
+)
+
+func (r *T) f(x, y int) (err error) {
+L:
+	for i := range x {
+		if i > 0 {
+			break L
+		}
+		switch {
+		case y > 0:
+			continue
+		}
+	}
+	fmt.Println(a[1:2:3], T{x: 1}, func() {})
+	return
+}
+`
+	f := mustParse(t, src, CheckBranches)
+	want := lineString(f)
+
+	c := Clone(f)
+	if got := lineString(c); got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	// no node of the original tree must appear in the copy
+	orig := make(map[Node]bool)
+	Inspect(f, func(n Node) bool {
+		orig[n] = true
+		return true
+	})
+	Inspect(c, func(n Node) bool {
+		if n != nil && orig[n] {
+			t.Errorf("%s: node %T shared with original", n.Pos(), n)
+		}
+		return true
+	})
+
+	// sharing is preserved
+	fn := c.DeclList[3].(*FuncDecl)
+	params := fn.Type.ParamList
+	if params[0].Type != params[1].Type {
+		t.Error("parameter types of x and y are not shared")
+	}
+	d0, d1 := c.DeclList[1].(*VarDecl), c.DeclList[2].(*VarDecl)
+	if d0.Group == nil || d0.Group != d1.Group || d0.Group == f.DeclList[1].(*VarDecl).Group {
+		t.Error("declaration group not copied correctly")
+	}
+
+	// branch targets refer to copies
+	Inspect(fn, func(n Node) bool {
+		if b, ok := n.(*BranchStmt); ok && orig[b.Target] {
+			t.Errorf("%s: branch target refers to original tree", b.Pos())
+		}
+		return true
+	})
+
+	// modifying the copy doesn't affect the original
+	fn.Name.Value = "g"
+	if got := lineString(f); got != want {
+		t.Errorf("original modified: %s", got)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	f := mustParse(t, "package p; func (r *T) f(x, y int) (err error) { return }", 0)
+	fn := f.DeclList[0].(*FuncDecl)
+	sig, recv := Signature(fn)
+	if sig == fn.Type || recv == fn.Recv {
+		t.Fatal("signature not copied")
+	}
+	if got, want := String(sig), "func(x, y int) (err error)"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := String(recv.Type), "*T"; got != want {
+		t.Errorf("got receiver type %s, want %s", got, want)
+	}
+
+	f = mustParse(t, "package p; func f()", 0)
+	if _, recv := Signature(f.DeclList[0].(*FuncDecl)); recv != nil {
+		t.Errorf("got receiver %s for function", String(recv.Type))
+	}
+}
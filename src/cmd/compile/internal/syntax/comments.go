@@ -0,0 +1,166 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the association of doc comments
+// with declarations.
+
+package syntax
+
+import "strings"
+
+// DocComments returns the doc comments of the declarations of file,
+// which must have been parsed in KeepComments mode: for each declaration
+// with a doc comment, the group of consecutive comments (without blank
+// lines between them) ending on the line immediately before the start
+// of the declaration, excluding any comments starting on the last line
+// of the preceding declaration, or package clause. For a declaration
+// within a group, as in const ( ... ), the doc comment of the group,
+// which ends on the line before the line with the keyword of the group,
+// is included as well, preceding the declaration's own doc comment;
+// since the position of the keyword is not recorded, the keyword and
+// the opening parenthesis are assumed to be on the line before the
+// first declaration of the group and its doc comment.
+func DocComments(file *File) map[Decl][]*Comment {
+	groups := commentGroups(file.Comments)
+	docs := make(map[Decl][]*Comment)
+	prevEnd := file.PkgName.Pos().Line() // last line of the preceding declaration
+	var prevGroup *Group
+	var groupDoc []*Comment // doc comment of the current group of declarations
+	for _, d := range file.DeclList {
+		start := StartPos(d).Line()
+		doc := docGroup(groups, start, prevEnd)
+
+		g := declGroup(d)
+		if g == nil || g != prevGroup {
+			groupDoc = nil
+			if g != nil {
+				kw := start - 1 // presumed line of the keyword
+				if doc != nil {
+					kw = doc[0].Pos().Line() - 1
+				}
+				groupDoc = docGroup(groups, kw, prevEnd)
+			}
+		}
+		prevGroup = g
+
+		if list := append(groupDoc[:len(groupDoc):len(groupDoc)], doc...); len(list) > 0 {
+			docs[d] = list
+		}
+		prevEnd = lastTokenPos(d).Line()
+	}
+	return docs
+}
+
+// commentGroups partitions the list of comments into groups of
+// consecutive comments, without blank lines between them.
+func commentGroups(list []*Comment) [][]*Comment {
+	var groups [][]*Comment
+	for i, c := range list {
+		if i > 0 && c.Pos().Line() > commentEnd(list[i-1])+1 {
+			groups = append(groups, nil)
+		} else if i == 0 {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], c)
+	}
+	return groups
+}
+
+// docGroup returns the comment group in groups which ends on the line
+// before the line start, without the comments starting on or before the
+// line prevEnd (which follow the preceding declaration), or nil.
+func docGroup(groups [][]*Comment, start, prevEnd uint) []*Comment {
+	for _, g := range groups {
+		if commentEnd(g[len(g)-1])+1 == start {
+			for len(g) > 0 && g[0].Pos().Line() <= prevEnd {
+				g = g[1:]
+			}
+			if len(g) > 0 {
+				return g
+			}
+		}
+	}
+	return nil
+}
+
+// commentEnd returns the line on which the comment c ends.
+func commentEnd(c *Comment) uint {
+	return c.Pos().Line() + uint(strings.Count(c.Text, "\n"))
+}
+
+// declGroup returns the group of the declaration d, or nil.
+func declGroup(d Decl) *Group {
+	switch d := d.(type) {
+	case *ImportDecl:
+		return d.Group
+	case *ConstDecl:
+		return d.Group
+	case *TypeDecl:
+		return d.Group
+	case *VarDecl:
+		return d.Group
+	}
+	return nil
+}
+
+// UndocumentedExports returns the declarations of exported objects in
+// file without a doc comment (see DocComments), in source order: the
+// declarations of exported functions, types, constants, and variables,
+// and of exported methods of exported types. A constant or variable
+// declaration is exported if any of the declared names is exported.
+// Doc comments consisting only of directives, such as //go:noinline,
+// do not count as documentation. The file must have been parsed in
+// KeepComments mode.
+func UndocumentedExports(file *File) []Decl {
+	docs := DocComments(file)
+	var list []Decl
+	for _, d := range file.DeclList {
+		if isExportedDecl(d) && !isDocumentation(docs[d]) {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+// isExportedDecl reports whether d declares an exported object.
+func isExportedDecl(d Decl) bool {
+	switch d := d.(type) {
+	case *ConstDecl:
+		return anyExported(d.NameList)
+	case *VarDecl:
+		return anyExported(d.NameList)
+	case *TypeDecl:
+		return isExported(d.Name.Value)
+	case *FuncDecl:
+		if !isExported(d.Name.Value) {
+			return false
+		}
+		if d.Recv != nil {
+			name := ReceiverType(d)
+			return name != nil && isExported(name.Value)
+		}
+		return true
+	}
+	return false
+}
+
+func anyExported(list []*Name) bool {
+	for _, name := range list {
+		if isExported(name.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDocumentation reports whether the doc comment
+// list contains a comment other than a directive.
+func isDocumentation(list []*Comment) bool {
+	for _, c := range list {
+		if !strings.HasPrefix(c.Text, "//go:") && !strings.HasPrefix(c.Text, "//line ") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocComments(t *testing.T) {
+	const src = `// Package p.
+package p
+
+import "fmt"
+
+// F does f.
+// More on F.
+func F() {}
+
+// Not a doc comment.
+
+func G() {}
+
+var x = 1 // trailing
+func H() {}
+
+// Consts.
+const (
+	// A is a.
+	A = 1
+	B = 2
+)
+
+type T struct{} /* T
+is
+T */
+/* U
+is
+U */
+type U int
+`
+	f := mustParse(t, src, KeepComments)
+	docs := DocComments(f)
+	var got []string
+	for _, d := range f.DeclList {
+		var text []string
+		for _, c := range docs[d] {
+			text = append(text, c.Text)
+		}
+		got = append(got, strings.ReplaceAll(strings.Join(text, " "), "\n", " "))
+	}
+	want := []string{
+		"", // import
+		"// F does f. // More on F.",
+		"",
+		"",
+		"",
+		"// Consts. // A is a.",
+		"// Consts.",
+		"",
+		"/* U is U */",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d declarations, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("declaration %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUndocumentedExports(t *testing.T) {
+	const src = `package p
+
+// F is documented.
+func F() {}
+func G() {}
+func g() {}
+
+//go:noinline
+func H() {}
+
+type T int
+
+// M is documented.
+func (T) M() {}
+func (*T) N() {}
+func (t t) O() {}
+
+// Vars.
+var (
+	V1 = 1
+	V2 = 2
+)
+
+const (
+	c = 0
+	// C is documented.
+	C = 1
+	D, e = 2, 3
+)
+`
+	f := mustParse(t, src, KeepComments)
+	var got []string
+	for _, d := range UndocumentedExports(f) {
+		got = append(got, lineString(d))
+	}
+	const want = "func G() {}; func H() {}; type T int; func (*T) N() {}; D, e = 2, 3"
+	if got := strings.Join(got, "; "); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
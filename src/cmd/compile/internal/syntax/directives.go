@@ -0,0 +1,58 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements helper functions for file-level directives
+// such as //go:build constraints.
+
+package syntax
+
+import (
+	"go/build/constraint"
+	"slices"
+	"strings"
+)
+
+// BuildConstraint returns the //go:build directive of file, or nil.
+func BuildConstraint(file *File) *Directive {
+	for _, d := range file.Directives {
+		if constraint.IsGoBuild(d.Text) {
+			return d
+		}
+	}
+	return nil
+}
+
+// SetBuildConstraint sets the //go:build constraint of file to the
+// boolean build constraint expression expr, such as "linux && !386",
+// and updates file.GoVersion accordingly. An existing //go:build
+// directive is replaced; otherwise a new directive is added before
+// all other directives. If expr is empty, the //go:build directive
+// is removed. The result is an error if expr is not a valid build
+// constraint expression; in that case file is not changed.
+func SetBuildConstraint(file *File, expr string) error {
+	d := BuildConstraint(file)
+
+	if expr == "" {
+		if d != nil {
+			file.Directives = slices.DeleteFunc(file.Directives, func(x *Directive) bool { return x == d })
+		}
+		file.GoVersion = ""
+		return nil
+	}
+
+	text := "//go:build " + strings.TrimSpace(expr)
+	x, err := constraint.Parse(text)
+	if err != nil {
+		return err
+	}
+
+	if d == nil {
+		d = new(Directive)
+		d.pos = MakePos(file.Pos().Base(), 1, 1)
+		file.Directives = slices.Insert(file.Directives, 0, d)
+	}
+	d.Text = text
+	file.GoVersion = constraint.GoVersion(x)
+	return nil
+}
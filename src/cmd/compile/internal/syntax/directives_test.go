@@ -0,0 +1,95 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileDirectives(t *testing.T) {
+	const src = `// Copyright notice.
+
+//go:build linux && go1.21
+/* not a //go:debug directive */
+
+//go:debug panicnil=1
+package p
+
+//go:noinline
+func f() {}
+`
+	f := mustParse(t, src, 0)
+
+	var got []string
+	Inspect(f, func(n Node) bool {
+		if d, ok := n.(*Directive); ok {
+			got = append(got, d.Text)
+		}
+		return true
+	})
+	if got, want := strings.Join(got, "\n"), "//go:build linux && go1.21\n//go:debug panicnil=1"; got != want {
+		t.Errorf("got directives\n%s\nwant\n%s", got, want)
+	}
+	if d := BuildConstraint(f); d != f.Directives[0] || d.Pos().Line() != 3 {
+		t.Errorf("got build constraint %v, want first directive in line 3", d)
+	}
+	if f.GoVersion != "go1.21" {
+		t.Errorf("got Go version %q, want go1.21", f.GoVersion)
+	}
+
+	// directives can be changed like other nodes
+	WalkAndChange(f, func(n *Node) bool {
+		if n != nil {
+			if d, ok := (*n).(*Directive); ok && strings.HasPrefix(d.Text, "//go:debug") {
+				d.Text = "//go:debug panicnil=0"
+			}
+		}
+		return true
+	})
+
+	var buf strings.Builder
+	if _, err := Fprint(&buf, f, 0); err != nil {
+		t.Fatal(err)
+	}
+	const want = "//go:build linux && go1.21\n//go:debug panicnil=0\n\npackage p\n\nfunc f() {}"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if err := Validate(f); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetBuildConstraint(t *testing.T) {
+	f := mustParse(t, "//go:debug x=1\npackage p", 0)
+
+	if err := SetBuildConstraint(f, "linux &&"); err == nil {
+		t.Error("invalid constraint accepted")
+	}
+
+	for _, test := range []struct {
+		expr, want, version string
+	}{
+		{"linux && go1.22", "//go:build linux && go1.22; //go:debug x=1", "go1.22"},
+		{"darwin", "//go:build darwin; //go:debug x=1", ""},
+		{"", "//go:debug x=1", ""},
+		{"", "//go:debug x=1", ""},
+	} {
+		if err := SetBuildConstraint(f, test.expr); err != nil {
+			t.Fatalf("%q: %v", test.expr, err)
+		}
+		var got []string
+		for _, d := range f.Directives {
+			got = append(got, String(d))
+		}
+		if got := strings.Join(got, "; "); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.expr, got, test.want)
+		}
+		if f.GoVersion != test.version {
+			t.Errorf("%q: got Go version %q, want %q", test.expr, f.GoVersion, test.version)
+		}
+	}
+}
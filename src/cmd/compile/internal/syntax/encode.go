@@ -0,0 +1,479 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements a compact binary encoding of syntax trees.
+
+package syntax
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Encoding format
+//
+// An encoded tree consists of a header (encodingMagic followed by the
+// encodingVersion byte), the encoding of the root node, and the table
+// of branch statement targets. All integers are varint-encoded.
+//
+// A node (or Group) is encoded as 0 if it is nil, as 1 followed by its
+// index if it was encoded before (nodes are indexed in the order they
+// are encoded), and otherwise as its kind (the index of its type in
+// encodedTypes) plus 2, followed by its position and its exported fields
+// in declaration order. Slices are encoded as 0 if they are nil, and as
+// their length plus 1 followed by their elements otherwise; strings are
+// encoded as their length followed by their bytes.
+//
+// A position is encoded as its base followed by its line and column.
+// A base is encoded as 0 if it is nil, as 1 followed by the base's
+// filename, trimmed flag, line, column, and position if it was not
+// encoded before, and as its index (in the order the bases are
+// encoded) plus 2 otherwise.
+//
+// The target table consists of the number of entries followed by
+// pairs of node indices of a branch statement and its target.
+//
+// The encoding of a tree depends on the order and the fields of the
+// node types; any change to them requires a new encodingVersion.
+
+const (
+	encodingMagic   = "gosyntax"
+	encodingVersion = 1
+)
+
+// encodedTypes lists the types of the encoded nodes; the index
+// of a type is the kind of the nodes of that type in an encoding.
+// New types must be appended.
+var encodedTypes = [...]reflect.Type{
+	reflect.TypeFor[*File](),
+	reflect.TypeFor[*Directive](),
+	reflect.TypeFor[*Group](),
+	reflect.TypeFor[*Comment](),
+
+	// declarations
+	reflect.TypeFor[*ImportDecl](),
+	reflect.TypeFor[*ConstDecl](),
+	reflect.TypeFor[*TypeDecl](),
+	reflect.TypeFor[*VarDecl](),
+	reflect.TypeFor[*FuncDecl](),
+
+	// expressions
+	reflect.TypeFor[*BadExpr](),
+	reflect.TypeFor[*Name](),
+	reflect.TypeFor[*BasicLit](),
+	reflect.TypeFor[*CompositeLit](),
+	reflect.TypeFor[*KeyValueExpr](),
+	reflect.TypeFor[*FuncLit](),
+	reflect.TypeFor[*ParenExpr](),
+	reflect.TypeFor[*SelectorExpr](),
+	reflect.TypeFor[*IndexExpr](),
+	reflect.TypeFor[*SliceExpr](),
+	reflect.TypeFor[*AssertExpr](),
+	reflect.TypeFor[*TypeSwitchGuard](),
+	reflect.TypeFor[*Operation](),
+	reflect.TypeFor[*CallExpr](),
+	reflect.TypeFor[*ListExpr](),
+
+	// types
+	reflect.TypeFor[*ArrayType](),
+	reflect.TypeFor[*SliceType](),
+	reflect.TypeFor[*DotsType](),
+	reflect.TypeFor[*StructType](),
+	reflect.TypeFor[*Field](),
+	reflect.TypeFor[*InterfaceType](),
+	reflect.TypeFor[*FuncType](),
+	reflect.TypeFor[*MapType](),
+	reflect.TypeFor[*ChanType](),
+
+	// statements
+	reflect.TypeFor[*EmptyStmt](),
+	reflect.TypeFor[*LabeledStmt](),
+	reflect.TypeFor[*BlockStmt](),
+	reflect.TypeFor[*ExprStmt](),
+	reflect.TypeFor[*SendStmt](),
+	reflect.TypeFor[*DeclStmt](),
+	reflect.TypeFor[*AssignStmt](),
+	reflect.TypeFor[*BranchStmt](),
+	reflect.TypeFor[*CallStmt](),
+	reflect.TypeFor[*ReturnStmt](),
+	reflect.TypeFor[*IfStmt](),
+	reflect.TypeFor[*ForStmt](),
+	reflect.TypeFor[*SwitchStmt](),
+	reflect.TypeFor[*SelectStmt](),
+	reflect.TypeFor[*RangeClause](),
+	reflect.TypeFor[*CaseClause](),
+	reflect.TypeFor[*CommClause](),
+}
+
+// encodedKinds maps the encoded node types to their kinds.
+var encodedKinds = func() map[reflect.Type]uint64 {
+	m := make(map[reflect.Type]uint64, len(encodedTypes))
+	for i, t := range encodedTypes {
+		m[t] = uint64(i)
+	}
+	return m
+}()
+
+// Encode returns a compact binary encoding of the syntax tree rooted
+// at root, which may be nil. The encoding is versioned; it is meant for
+// caching trees (for instance, in a build cache) rather than for
+// interchange. Decode restores the tree from the encoding, including
+// the positions of all nodes, comments, shared nodes (such as the type
+// of fields declared in a group), declaration groups, and the targets
+// of branch statements. Pragmas and type information are not encoded.
+func Encode(root Node) ([]byte, error) {
+	e := encoder{
+		buf:   append([]byte(encodingMagic), encodingVersion),
+		nodes: make(map[any]uint64),
+		bases: make(map[*PosBase]uint64),
+	}
+	var v reflect.Value
+	if root != nil {
+		v = reflect.ValueOf(root)
+	}
+	if err := e.node(v); err != nil {
+		return nil, err
+	}
+
+	// branch statement targets inside the tree
+	var targets []uint64
+	for _, b := range e.branches {
+		if t, ok := e.nodes[b.Target]; ok {
+			targets = append(targets, e.nodes[b], t)
+		}
+	}
+	e.uint(uint64(len(targets) / 2))
+	for _, x := range targets {
+		e.uint(x)
+	}
+	return e.buf, nil
+}
+
+type encoder struct {
+	buf      []byte
+	nodes    map[any]uint64      // encoded node or group -> index
+	bases    map[*PosBase]uint64 // encoded base -> index
+	branches []*BranchStmt       // encoded branch statements with targets
+}
+
+func (e *encoder) uint(x uint64) { e.buf = binary.AppendUvarint(e.buf, x) }
+func (e *encoder) int(x int64)   { e.buf = binary.AppendVarint(e.buf, x) }
+
+func (e *encoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) bool(b bool) {
+	if b {
+		e.uint(1)
+	} else {
+		e.uint(0)
+	}
+}
+
+func (e *encoder) pos(pos Pos) {
+	e.base(pos.base)
+	e.uint(uint64(pos.line))
+	e.uint(uint64(pos.col))
+}
+
+func (e *encoder) base(b *PosBase) {
+	if b == nil {
+		e.uint(0)
+		return
+	}
+	if i, ok := e.bases[b]; ok {
+		e.uint(i + 2)
+		return
+	}
+	e.bases[b] = uint64(len(e.bases))
+	e.uint(1)
+	e.string(b.filename)
+	e.bool(b.trimmed)
+	e.uint(uint64(b.line))
+	e.uint(uint64(b.col))
+	e.pos(b.pos) // the base of a file base is the base itself
+}
+
+// node encodes the (possibly nil or invalid) node or group pointer v.
+func (e *encoder) node(v reflect.Value) error {
+	if !v.IsValid() || v.IsNil() {
+		e.uint(0)
+		return nil
+	}
+	key := v.Interface()
+	if i, ok := e.nodes[key]; ok {
+		e.uint(1)
+		e.uint(i)
+		return nil
+	}
+	kind, ok := encodedKinds[v.Type()]
+	if !ok {
+		return fmt.Errorf("syntax.Encode: unexpected node type %s", v.Type())
+	}
+	e.nodes[key] = uint64(len(e.nodes))
+	e.uint(kind + 2)
+	if n, ok := key.(Node); ok {
+		e.pos(n.Pos())
+	}
+	if b, ok := key.(*BranchStmt); ok && b.Target != nil {
+		e.branches = append(e.branches, b)
+	}
+	x := v.Elem()
+	t := x.Type()
+	for i := range t.NumField() {
+		if f := t.Field(i); f.IsExported() && !(t == branchStmtType && f.Name == "Target") {
+			if err := e.value(x.Field(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// value encodes the field value v.
+func (e *encoder) value(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		e.bool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		e.uint(v.Uint())
+	case reflect.String:
+		e.string(v.String())
+	case reflect.Struct:
+		if v.Type() != posType {
+			return fmt.Errorf("syntax.Encode: unexpected field type %s", v.Type())
+		}
+		e.pos(v.Interface().(Pos))
+	case reflect.Pointer:
+		return e.node(v)
+	case reflect.Interface:
+		if v.IsNil() || !v.Elem().Type().Implements(nodeType) {
+			e.uint(0) // not a node (e.g., a Pragma)
+			return nil
+		}
+		return e.node(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.uint(0)
+			return nil
+		}
+		e.uint(uint64(v.Len()) + 1)
+		fallthrough
+	case reflect.Array:
+		for i := range v.Len() {
+			if err := e.value(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("syntax.Encode: unexpected field type %s", v.Type())
+	}
+	return nil
+}
+
+// Decode returns the syntax tree encoded by Encode in data.
+// It reports an error if data is not a valid encoding, or if
+// it was produced by a different version of Encode.
+func Decode(data []byte) (root Node, err error) {
+	if len(data) <= len(encodingMagic) || string(data[:len(encodingMagic)]) != encodingMagic {
+		return nil, errors.New("syntax.Decode: not an encoded syntax tree")
+	}
+	if v := data[len(encodingMagic)]; v != encodingVersion {
+		return nil, fmt.Errorf("syntax.Decode: unsupported encoding version %d", v)
+	}
+
+	d := decoder{buf: data[len(encodingMagic)+1:]}
+	defer func() {
+		if e := recover(); e != nil {
+			derr, ok := e.(decodeError)
+			if !ok {
+				panic(e)
+			}
+			root, err = nil, derr.err
+		}
+	}()
+
+	d.value(reflect.ValueOf(&root).Elem())
+	for range d.uint() {
+		b, ok := d.nodeAt(d.uint()).(*BranchStmt)
+		t, isStmt := d.nodeAt(d.uint()).(Stmt)
+		if !ok || !isStmt {
+			d.errorf("invalid branch target")
+		}
+		b.Target = t
+	}
+	if len(d.buf) > 0 {
+		d.errorf("unexpected data after syntax tree")
+	}
+	return root, nil
+}
+
+type decoder struct {
+	buf   []byte     // remaining data
+	nodes []any      // decoded nodes and groups, by index
+	bases []*PosBase // decoded bases, by index
+}
+
+// A decodeError is raised (via panic) by a decoder
+// and recovered in Decode.
+type decodeError struct{ err error }
+
+func (d *decoder) errorf(format string, args ...any) {
+	panic(decodeError{fmt.Errorf("syntax.Decode: "+format, args...)})
+}
+
+func (d *decoder) uint() uint64 {
+	x, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.errorf("invalid or truncated data")
+	}
+	d.buf = d.buf[n:]
+	return x
+}
+
+func (d *decoder) int() int64 {
+	x, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.errorf("invalid or truncated data")
+	}
+	d.buf = d.buf[n:]
+	return x
+}
+
+func (d *decoder) string() string {
+	n := d.uint()
+	if n > uint64(len(d.buf)) {
+		d.errorf("invalid or truncated data")
+	}
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
+
+func (d *decoder) bool() bool {
+	return d.uint() != 0
+}
+
+func (d *decoder) uint32() uint32 {
+	x := d.uint()
+	if x > 1<<32-1 {
+		d.errorf("invalid position")
+	}
+	return uint32(x)
+}
+
+func (d *decoder) pos() Pos {
+	b := d.base()
+	return Pos{b, d.uint32(), d.uint32()}
+}
+
+func (d *decoder) base() *PosBase {
+	switch i := d.uint(); i {
+	case 0:
+		return nil
+	case 1:
+		b := new(PosBase)
+		d.bases = append(d.bases, b)
+		b.filename = d.string()
+		b.trimmed = d.bool()
+		b.line = d.uint32()
+		b.col = d.uint32()
+		b.pos = d.pos()
+		return b
+	default:
+		if i-2 >= uint64(len(d.bases)) {
+			d.errorf("invalid position base")
+		}
+		return d.bases[i-2]
+	}
+}
+
+// nodeAt returns the decoded node or group with index i.
+func (d *decoder) nodeAt(i uint64) any {
+	if i >= uint64(len(d.nodes)) {
+		d.errorf("invalid node reference")
+	}
+	return d.nodes[i]
+}
+
+// node decodes a node or group and returns it, or
+// the invalid value if the encoded node is nil.
+func (d *decoder) node() reflect.Value {
+	var kind uint64
+	switch kind = d.uint(); kind {
+	case 0:
+		return reflect.Value{}
+	case 1:
+		return reflect.ValueOf(d.nodeAt(d.uint()))
+	}
+	if kind-2 >= uint64(len(encodedTypes)) {
+		d.errorf("invalid node kind %d", kind)
+	}
+	t := encodedTypes[kind-2]
+	v := reflect.New(t.Elem())
+	d.nodes = append(d.nodes, v.Interface())
+	if n, ok := v.Interface().(Node); ok {
+		n.SetPos(d.pos())
+	}
+	x := v.Elem()
+	for i := range t.Elem().NumField() {
+		if f := t.Elem().Field(i); f.IsExported() && !(t.Elem() == branchStmtType && f.Name == "Target") {
+			d.value(x.Field(i))
+		}
+	}
+	return v
+}
+
+// value decodes the field value v.
+func (d *decoder) value(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(d.bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x := d.int()
+		if v.OverflowInt(x) {
+			d.errorf("value %d overflows %s", x, v.Type())
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x := d.uint()
+		if v.OverflowUint(x) {
+			d.errorf("value %d overflows %s", x, v.Type())
+		}
+		v.SetUint(x)
+	case reflect.String:
+		v.SetString(d.string())
+	case reflect.Struct:
+		v.Set(reflect.ValueOf(d.pos()))
+	case reflect.Pointer, reflect.Interface:
+		x := d.node()
+		if !x.IsValid() {
+			return // leave v nil
+		}
+		if !x.Type().AssignableTo(v.Type()) {
+			d.errorf("unexpected %s for field of type %s", x.Type(), v.Type())
+		}
+		v.Set(x)
+	case reflect.Slice:
+		n := d.uint()
+		if n == 0 {
+			return // leave v nil
+		}
+		if n-1 > uint64(len(d.buf)) {
+			d.errorf("invalid or truncated data")
+		}
+		v.Set(reflect.MakeSlice(v.Type(), int(n-1), int(n-1)))
+		fallthrough
+	case reflect.Array:
+		for i := range v.Len() {
+			d.value(v.Index(i))
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// positions returns the kinds and positions of the
+// nodes in the tree rooted at root, in pre-order.
+func positions(root Node) []string {
+	var list []string
+	Inspect(root, func(n Node) bool {
+		if n != nil {
+			list = append(list, nodeKind(n)+"@"+n.Pos().String())
+		}
+		return true
+	})
+	return list
+}
+
+func testEncodeRoundTrip(t *testing.T, name string, f *File) {
+	t.Helper()
+	data, err := Encode(f)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	n, err := Decode(data)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	g, ok := n.(*File)
+	if !ok {
+		t.Fatalf("%s: decoded %T, want *File", name, n)
+	}
+	if !Equal(f, g) {
+		t.Errorf("%s: decoded tree differs from the original", name)
+	}
+	if !slices.Equal(positions(f), positions(g)) {
+		t.Errorf("%s: decoded positions differ from the original", name)
+	}
+	if f.EOF.String() != g.EOF.String() || len(f.Comments) != len(g.Comments) {
+		t.Errorf("%s: decoded file attributes differ from the original", name)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	const src = `//go:build ignore
+
+// Package p.
+package p
+
+import (
+	"fmt"
+	m "math"
+)
+
+const (
+	a, b = iota, 1.5
+	c
+)
+
+type (
+	T[P any, Q ~int | string] struct {
+		x, y int ` + "`tag`" + `
+		*E
+	}
+	I interface{ m(chan<- int, <-chan string) (int, error) }
+)
+
+var v = map[string][]*T[int, int]{"k": {{x: 1}}}
+
+//line other.go:10:1
+func (t *T[P, Q]) f(args ...any) {
+L:
+	for i := 0; i < 10; i++ {
+		switch x := args[0].(type) {
+		case int:
+			break L
+		default:
+			_ = x
+			continue
+		}
+	}
+	for k, v := range v {
+		_, _ = k, v[1:2:3]
+	}
+	select {
+	case ch <- 1:
+	case x, ok := <-ch:
+		_ = ok && !x
+	default:
+	}
+	if x := -1; x > 0 {
+		goto L
+	} else if false {
+	}
+	defer fmt.Println(m.Pi, 'c', 1i, func() {}, [...]int{1}, t.x.(int))
+	go f()
+	x++
+	x += 2
+	return
+}
+`
+	f := mustParse(t, src, CheckBranches|KeepComments)
+	testEncodeRoundTrip(t, "src", f)
+
+	// shared nodes, groups, and branch targets are preserved
+	data, err := Encode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := n.(*File)
+	s := g.DeclList[4].(*TypeDecl).Type.(*StructType)
+	if s.FieldList[0].Type != s.FieldList[1].Type {
+		t.Errorf("field type is not shared")
+	}
+	if g.DeclList[2].(*ConstDecl).Group != g.DeclList[3].(*ConstDecl).Group {
+		t.Errorf("group is not shared")
+	}
+	fn := funcDecl(t, g, "f")
+	loop := fn.Body.List[0].(*LabeledStmt)
+	var targets []Stmt
+	Inspect(fn, func(n Node) bool {
+		if b, ok := n.(*BranchStmt); ok {
+			targets = append(targets, b.Target)
+		}
+		return true
+	})
+	if len(targets) != 3 || targets[0] != loop.Stmt || targets[2] != loop {
+		t.Errorf("got branch targets %v", targets)
+	}
+
+	// the package sources
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		f, err := ParseFile(name, nil, nil, CheckBranches|KeepComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testEncodeRoundTrip(t, name, f)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	f := mustParse(t, "package p; func f() { if x { g(1, \"s\") } }", 0)
+	data, err := Encode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := Decode(data); err != nil || n == nil {
+		t.Fatalf("got %v, %v", n, err)
+	}
+
+	// truncated or extended data is rejected
+	for i := range data {
+		if _, err := Decode(data[:i]); err == nil {
+			t.Errorf("no error for data truncated to %d bytes", i)
+		}
+	}
+	if _, err := Decode(append(slices.Clip(data), 0)); err == nil {
+		t.Errorf("no error for trailing data")
+	}
+
+	// data of a different version is rejected
+	other := slices.Clone(data)
+	other[len(encodingMagic)]++
+	if _, err := Decode(other); err == nil {
+		t.Errorf("no error for unsupported version")
+	}
+
+	// nil round-trips
+	data, err = Encode(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := Decode(data); err != nil || n != nil {
+		t.Errorf("got %v, %v for nil", n, err)
+	}
+}
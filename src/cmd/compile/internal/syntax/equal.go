@@ -0,0 +1,186 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements structural equality of syntax trees.
+
+package syntax
+
+import (
+	"bufio"
+	"cmp"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Equal reports whether the syntax trees rooted at x and y are
+// structurally equal: they consist of the same kinds of nodes with the
+// same attributes (such as identifier names, literal values, and
+// operators), regardless of positions, comments, and pragmas. Literal
+// values are compared as written; for instance, 0x10 and 16 are not
+// equal. Declarations are considered equal with respect to grouping if
+// they are both grouped or both not grouped. Two nil nodes are equal.
+func Equal(x, y Node) bool {
+	if isNilNode(x) || isNilNode(y) {
+		return isNilNode(x) && isNilNode(y)
+	}
+	v, w := reflect.ValueOf(x), reflect.ValueOf(y)
+	return v.Type() == w.Type() && equalValues(v, w, nil)
+}
+
+// EqualSemantic is like Equal but considers the operands of commutative
+// operations as unordered: binary operations with one of the operators
+// &&, ||, +, *, ==, and != are equal if their operands are equal in any
+// order, and sequences of operations with the same associative operator
+// (&&, ||, +, and *), such as a && b && c, are equal if they have the
+// same operands in any order. For instance, a && b && c is equal to
+// c && b && a, but not to c && (b && a), since parentheses are
+// significant as for Equal. Operations with other operators, such as
+// - or <, are compared as with Equal.
+//
+// Without type information, + is assumed to be commutative unless one of
+// the operands of a sequence of additions is a string literal; string
+// concatenations of variables are thus considered commutative. Also, the
+// order of the operands of && and || determines which operands are
+// evaluated, which matters if they have side effects.
+func EqualSemantic(x, y Node) bool {
+	if isNilNode(x) || isNilNode(y) {
+		return isNilNode(x) && isNilNode(y)
+	}
+	return Equal(normalizeOperations(Clone(x)), normalizeOperations(Clone(y)))
+}
+
+// normalizeOperations orders the operands of the commutative operations
+// in the tree rooted at root, in place, and returns the resulting tree.
+// The operands of sequences of operations with the same associative
+// operator are ordered as a whole and combined from left to right.
+func normalizeOperations(root Node) Node {
+	return RewriteBottomUp(root, func(n Node) Node {
+		x, ok := n.(*Operation)
+		if !ok || x.Y == nil {
+			return n
+		}
+		switch x.Op {
+		case Eql, Neq:
+			if canonicalString(x.X) > canonicalString(x.Y) {
+				x.X, x.Y = x.Y, x.X
+			}
+		case AndAnd, OrOr, Add, Mul:
+			list := operands(x, x.Op, nil)
+			if x.Op == Add && slices.ContainsFunc(list, isStringLit) {
+				return n // string concatenation
+			}
+			keys := make(map[Expr]string, len(list))
+			for _, y := range list {
+				keys[y] = canonicalString(y)
+			}
+			slices.SortStableFunc(list, func(a, b Expr) int {
+				return cmp.Compare(keys[a], keys[b])
+			})
+			// reuse the operation nodes of the sequence,
+			// from left to right, to combine the operands
+			ops := operations(x, x.Op, nil)
+			y := list[0]
+			for i, op := range ops {
+				op.X, op.Y = y, list[i+1]
+				y = op
+			}
+			return y
+		}
+		return n
+	})
+}
+
+// operands appends the operands of the sequence of binary operations
+// with the operator op rooted at x to list, from left to right.
+func operands(x Expr, op Operator, list []Expr) []Expr {
+	if y, ok := x.(*Operation); ok && y.Op == op && y.Y != nil {
+		list = operands(y.X, op, list)
+		return operands(y.Y, op, list)
+	}
+	return append(list, x)
+}
+
+// operations appends the binary operations with the operator op of
+// the sequence rooted at x to list, in post-order.
+func operations(x Expr, op Operator, list []*Operation) []*Operation {
+	if y, ok := x.(*Operation); ok && y.Op == op && y.Y != nil {
+		list = operations(y.X, op, list)
+		list = operations(y.Y, op, list)
+		return append(list, y)
+	}
+	return list
+}
+
+// canonicalString returns the serialization of the structure
+// of the tree rooted at n, as used by Fingerprint.
+func canonicalString(n Node) string {
+	var buf strings.Builder
+	w := bufio.NewWriter(&buf)
+	e := fingerprinter{w: w, groups: make(map[*Group]int)}
+	e.value(reflect.ValueOf(n))
+	w.Flush()
+	return buf.String()
+}
+
+// equalValues reports whether v and w, which have the same type and are
+// (possibly nil) nodes or values contained in nodes, are structurally equal.
+// If holes is not nil, v is a pattern whose wildcards (see FindMatches)
+// match any expression in w; the expressions matched by named wildcards
+// are recorded in holes.
+func equalValues(v, w reflect.Value, holes map[string]Node) bool {
+	if holes != nil && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && !v.IsNil() && !w.IsNil() {
+		if name, ok := v.Interface().(*Name); ok && isWildcard(name) {
+			return bind(holes, name.Value, w.Interface())
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || w.IsNil() {
+			return v.IsNil() == w.IsNil()
+		}
+		if v.Type() == groupType {
+			return true // both grouped
+		}
+		t := v.Type().Elem()
+		for i := range t.NumField() {
+			if isStructural(t, t.Field(i)) && !equalValues(v.Elem().Field(i), w.Elem().Field(i), holes) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Interface:
+		if v.IsNil() || w.IsNil() {
+			return v.IsNil() == w.IsNil()
+		}
+		return v.Elem().Type() == w.Elem().Type() && equalValues(v.Elem(), w.Elem(), holes)
+
+	case reflect.Slice, reflect.Array:
+		if v.Len() != w.Len() {
+			return false
+		}
+		for i := range v.Len() {
+			if !equalValues(v.Index(i), w.Index(i), holes) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.String:
+		return v.String() == w.String()
+
+	case reflect.Bool:
+		return v.Bool() == w.Bool()
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == w.Uint()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == w.Int()
+	}
+
+	panic("internal error: unexpected value of type " + v.Type().String())
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	for _, test := range []struct {
+		x, y string
+		want bool
+	}{
+		{"x", "x", true},
+		{"x", "y", false},
+		{"x", "(x)", false},
+		{"a.b[i]", "a . b [ i ]", true},
+		{"f(x, y)", "f(x,\n\ty,\n)", true},
+		{"f(x...)", "f(x)", false},
+		{"1", "1", true},
+		{"1", "0x1", false},
+		{"1", "1.0", false},
+		{"x + y", "x - y", false},
+		{"x + y", "y + x", false},
+		{"-x", "x", false},
+		{"[]int{1, 2}", "[]int{1, 2, }", true},
+		{"[]int{1, 2}", "[]int{2, 1}", false},
+		{"func() { L: for { break L } }", "func() {\nL:\n\tfor {\n\t\tbreak L\n\t}\n}", true},
+		{"func() { L: for { break L } }", "func() { M: for { break M } }", false},
+		{"struct{ x int `a` }{}", "struct{ x int `a` }{}", true},
+		{"struct{ x int `a` }{}", `struct{ x int "a" }{}`, false},
+		{"struct{ x, y int }{}", "struct{ x int; y int }{}", true},
+	} {
+		x := mustParse(t, "package p; var _ = "+test.x, 0).DeclList[0].(*VarDecl).Values
+		y := mustParse(t, "package p; var _ = "+test.y, 0).DeclList[0].(*VarDecl).Values
+		if got := Equal(x, y); got != test.want {
+			t.Errorf("Equal(%s, %s) = %v, want %v", test.x, test.y, got, test.want)
+		}
+	}
+
+	// Equal ignores comments and declaration positions,
+	// but not declaration grouping.
+	const src = `package p
+
+var (
+	a = 1 // one
+)
+
+func f() {}
+`
+	f := mustParse(t, src, KeepComments)
+	if !Equal(f, mustParse(t, "package p; var (a = 1); func f() {}", 0)) {
+		t.Errorf("reformatted file is not equal")
+	}
+	if Equal(f, mustParse(t, "package p; var a = 1; func f() {}", 0)) {
+		t.Errorf("ungrouped declaration is equal")
+	}
+	if !Equal(nil, nil) || !Equal(nil, (*File)(nil)) || Equal(f, nil) {
+		t.Errorf("unexpected result for nil nodes")
+	}
+}
+
+func TestEqualSemantic(t *testing.T) {
+	for _, test := range []struct {
+		x, y string
+		want bool
+	}{
+		{"x", "x", true},
+		{"a && b", "b && a", true},
+		{"a || b", "b || a", true},
+		{"x + y", "y + x", true},
+		{"x * y", "y * x", true},
+		{"x == y", "y == x", true},
+		{"x != y", "y != x", true},
+		{"x - y", "y - x", false},
+		{"x / y", "y / x", false},
+		{"x < y", "y < x", false},
+		{"x < y", "y > x", false},
+		{"a && b && c", "c && b && a", true},
+		{"a && b && c", "b && (c && a)", false},
+		{"a && b || c", "c || b && a", true},
+		{"a && b || c", "a && c || b", false},
+		{"x + y + z", "z + x + y", true},
+		{"x + y * z", "z * y + x", true},
+		{"x + y * z", "(x + y) * z", false},
+		{`"a" + s`, `s + "a"`, false},
+		{`s + t`, `t + s`, true},
+		{"f(a == b, c)", "f(b == a, c)", true},
+		{"f(a, b)", "f(b, a)", false},
+		{"a && b", "a || b", false},
+		{"a && a && b", "a && b && b", false},
+	} {
+		x := mustParse(t, "package p; var _ = "+test.x, 0).DeclList[0].(*VarDecl).Values
+		y := mustParse(t, "package p; var _ = "+test.y, 0).DeclList[0].(*VarDecl).Values
+		if got := EqualSemantic(x, y); got != test.want {
+			t.Errorf("EqualSemantic(%s, %s) = %v, want %v", test.x, test.y, got, test.want)
+		}
+		if got := lineString(x); got != test.x {
+			t.Errorf("EqualSemantic modified %s: got %s", test.x, got)
+		}
+	}
+}
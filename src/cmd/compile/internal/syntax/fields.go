@@ -0,0 +1,249 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements helper functions for fields,
+// parameters, and function signatures.
+
+package syntax
+
+import (
+	"sort"
+	"strings"
+)
+
+// InterfaceMethods partitions the elements of the interface it into
+// the explicitly declared methods (fields with a name and a *FuncType
+// type) and the embedded elements (fields without a name), which are
+// embedded interfaces or, for constraint interfaces, type terms and
+// unions such as ~int | string. Both lists are in source order.
+func InterfaceMethods(it *InterfaceType) (methods []*Field, embedded []Expr) {
+	for _, f := range it.MethodList {
+		if f.Name != nil {
+			methods = append(methods, f)
+		} else {
+			embedded = append(embedded, f.Type)
+		}
+	}
+	return
+}
+
+// FlattenInterface returns the method set of the interface it, with the
+// embedded interfaces declared in file replaced by their methods, which
+// are flattened recursively: the explicitly declared methods of it and,
+// in place of each embedded interface, the methods of that interface, in
+// source order. A method is included only once if it is reachable through
+// several embedded interfaces; the first occurrence is kept. Embedded
+// interfaces are resolved by name to the package-level type declarations
+// of file, following aliases; embedded interfaces declared in other files
+// or packages (such as io.Reader), instantiated generic interfaces, and
+// other embedded elements such as type unions cannot be resolved and are
+// included as (unnamed) fields as is. Cycles of embedded interfaces,
+// which are invalid, are followed only once.
+func FlattenInterface(file *File, it *InterfaceType) []*Field {
+	types := make(map[string]Expr)
+	for _, d := range file.DeclList {
+		if d, ok := d.(*TypeDecl); ok && d.TParamList == nil {
+			types[d.Name.Value] = d.Type
+		}
+	}
+
+	var list []*Field
+	methods := make(map[string]bool)
+	seen := make(map[*InterfaceType]bool)
+	var flatten func(it *InterfaceType)
+	flatten = func(it *InterfaceType) {
+		seen[it] = true
+		for _, f := range it.MethodList {
+			if f.Name != nil {
+				if !methods[f.Name.Value] {
+					methods[f.Name.Value] = true
+					list = append(list, f)
+				}
+				continue
+			}
+			if e := resolveInterface(types, f.Type); e != nil {
+				if !seen[e] {
+					flatten(e)
+				}
+				continue
+			}
+			list = append(list, f)
+		}
+	}
+	flatten(it)
+	return list
+}
+
+// resolveInterface returns the interface type denoted by the type name x
+// according to types, which maps type names to their types, or nil.
+func resolveInterface(types map[string]Expr, x Expr) *InterfaceType {
+	for range len(types) + 1 { // stop at alias cycles
+		switch t := Unparen(x).(type) {
+		case *Name:
+			x = types[t.Value]
+		case *InterfaceType:
+			return t
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// Signature returns a copy of the signature (parameters and results)
+// of fn and, for methods, a copy of the receiver; recv is nil if fn
+// is not a method. The copies are independent of fn and may be modified
+// or used to construct new declarations.
+func Signature(fn *FuncDecl) (sig *FuncType, recv *Field) {
+	sig = Clone(fn.Type)
+	if fn.Recv != nil {
+		recv = Clone(fn.Recv)
+	}
+	return
+}
+
+// SortFields sorts the fields of the struct type st in place, using
+// less to compare fields, and keeps the field tags (st.TagList) in sync
+// with the fields. The sort is stable. Note that fields declared together
+// (as in a, b int) share the same type node, which is retained; they are
+// printed separately if they are not adjacent after sorting.
+func SortFields(st *StructType, less func(a, b *Field) bool) {
+	type field struct {
+		f   *Field
+		tag *BasicLit
+	}
+	list := make([]field, len(st.FieldList))
+	for i, f := range st.FieldList {
+		list[i].f = f
+		if i < len(st.TagList) {
+			list[i].tag = st.TagList[i]
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return less(list[i].f, list[j].f)
+	})
+
+	var tags []*BasicLit
+	for i, x := range list {
+		st.FieldList[i] = x.f
+		if x.tag != nil {
+			// TagList ends with the last tagged field
+			for len(tags) < i {
+				tags = append(tags, nil)
+			}
+			tags = append(tags, x.tag)
+		}
+	}
+	st.TagList = tags
+}
+
+// ByFieldName reports whether the name of field a sorts before the
+// name of field b. The name of an embedded field is the name of its
+// type (T for embedded fields of the form T, *T, or pkg.T); an embedded
+// field whose type has no name sorts first.
+func ByFieldName(a, b *Field) bool {
+	return fieldName(a) < fieldName(b)
+}
+
+// fieldName returns the (possibly implicit) name of field f.
+func fieldName(f *Field) string {
+	if f.Name != nil {
+		return f.Name.Value
+	}
+	x := Unparen(f.Type)
+	if op, ok := x.(*Operation); ok && op.Op == Mul && op.Y == nil {
+		x = Unparen(op.X)
+	}
+	if sel, ok := x.(*SelectorExpr); ok {
+		return sel.Sel.Value
+	}
+	if name := baseTypeName(x); name != nil {
+		return name.Value
+	}
+	return ""
+}
+
+// DuplicateFields returns the groups of fields of the struct type st
+// which have the same name, in the order of the first field of each
+// group; the fields of each group are in source order. The name of an
+// embedded field is the name of its type (see ByFieldName), so that, for
+// instance, the embedded fields T, *T, and pkg.T, and a field named T all
+// conflict. Blank fields (named _) do not conflict with each other.
+func DuplicateFields(st *StructType) [][]*Field {
+	var names []string // field names in order of first occurrence
+	fields := make(map[string][]*Field)
+	for _, f := range st.FieldList {
+		name := fieldName(f)
+		if name == "" || name == "_" {
+			continue
+		}
+		if fields[name] == nil {
+			names = append(names, name)
+		}
+		fields[name] = append(fields[name], f)
+	}
+
+	var groups [][]*Field
+	for _, name := range names {
+		if list := fields[name]; len(list) > 1 {
+			groups = append(groups, list)
+		}
+	}
+	return groups
+}
+
+// SortCases sorts the case clauses of the switch statement sw in place,
+// using less to compare clauses. The sort is stable. It is the caller's
+// responsibility to ensure that the reordering preserves the meaning of
+// the switch statement; for instance, clauses ending in a fallthrough
+// statement should not be moved.
+func SortCases(sw *SwitchStmt, less func(a, b *CaseClause) bool) {
+	sort.SliceStable(sw.Body, func(i, j int) bool {
+		return less(sw.Body[i], sw.Body[j])
+	})
+}
+
+// ParamCount returns the number of parameters of the function or
+// method fn, not counting the receiver. Since the parser represents
+// each parameter of a group (as in a, b, c int) by its own Field (with
+// a shared type), this is the length of the parameter list; a variadic
+// parameter counts as one.
+func ParamCount(fn *FuncDecl) int {
+	return len(fn.Type.ParamList)
+}
+
+// ResultCount returns the number of results of the function or method
+// fn, not counting a trailing error result, and whether fn has such a
+// result. As for ParamCount, each result of a group (as in x, y int)
+// counts as one. Without type information, the last result is assumed
+// to be an error if it is named err, or if its type is error or a
+// (possibly qualified or pointer) type name ending in Error, as in
+// *PathError or net.Error.
+func ResultCount(fn *FuncDecl) (values int, hasError bool) {
+	list := fn.Type.ResultList
+	if len(list) > 0 && isErrorResult(list[len(list)-1]) {
+		return len(list) - 1, true
+	}
+	return len(list), false
+}
+
+// isErrorResult reports whether the result f looks like an error.
+func isErrorResult(f *Field) bool {
+	if f.Name != nil && f.Name.Value == "err" {
+		return true
+	}
+	typ := Unparen(f.Type)
+	if op, ok := typ.(*Operation); ok && op.Op == Mul && op.Y == nil {
+		typ = Unparen(op.X)
+	}
+	var name string
+	switch typ := typ.(type) {
+	case *Name:
+		name = typ.Value
+	case *SelectorExpr:
+		name = typ.Sel.Value
+	}
+	return name == "error" || strings.HasSuffix(name, "Error")
+}
@@ -0,0 +1,222 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+// typeExpr returns the type of the (first) type declaration named name in f.
+func typeExpr(t *testing.T, f *File, name string) Expr {
+	t.Helper()
+	for _, d := range f.DeclList {
+		if d, ok := d.(*TypeDecl); ok && d.Name.Value == name {
+			return d.Type
+		}
+	}
+	t.Fatalf("type %s not found", name)
+	return nil
+}
+
+// fieldStrings returns the (ShortForm) strings of the fields in list.
+func fieldStrings(list []*Field) string {
+	var s []string
+	for _, f := range list {
+		if f.Name != nil {
+			s = append(s, f.Name.Value+" "+String(f.Type))
+		} else {
+			s = append(s, String(f.Type))
+		}
+	}
+	return strings.Join(s, "; ")
+}
+
+func TestInterfaceMethods(t *testing.T) {
+	const src = `package p
+
+type I interface {
+	io.Reader
+	m(x int) bool
+	Stringer
+	~int | string
+	n()
+}
+`
+	f := mustParse(t, src, 0)
+	methods, embedded := InterfaceMethods(typeExpr(t, f, "I").(*InterfaceType))
+	if got, want := fieldStrings(methods), "m func(x int) bool; n func()"; got != want {
+		t.Errorf("methods: got %s, want %s", got, want)
+	}
+	if got, want := nodeStrings(embedded), "io.Reader; Stringer; ~int | string"; got != want {
+		t.Errorf("embedded: got %s, want %s", got, want)
+	}
+}
+
+func TestFlattenInterface(t *testing.T) {
+	const src = `package p
+
+type I interface {
+	io.Reader
+	m()
+	J
+	K
+	~int | string
+}
+
+type J interface {
+	a()
+	K
+}
+
+type K = interface {
+	b()
+	m()
+	L
+}
+
+type L interface {
+	J
+	c() int
+}
+
+type S struct{ J }
+
+type G[P any] interface{ g() }
+
+type X interface {
+	S
+	G[int]
+	Y
+}
+
+type Y = Y
+`
+	f := mustParse(t, src, 0)
+	for _, test := range []struct {
+		name, want string
+	}{
+		{"I", "io.Reader; m func(); a func(); b func(); c func() int; ~int | string"},
+		{"L", "a func(); b func(); m func(); c func() int"},
+		{"X", "S; G[int]; Y"},
+	} {
+		got := fieldStrings(FlattenInterface(f, typeExpr(t, f, test.name).(*InterfaceType)))
+		if got != test.want {
+			t.Errorf("%s: got  %s\nwant %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSortFields(t *testing.T) {
+	const src = "package p; type S struct { c int `c`; *pkg.B; a, d string; e bool `e`; x.Z }"
+	f := mustParse(t, src, 0)
+	st := typeExpr(t, f, "S").(*StructType)
+
+	SortFields(st, ByFieldName)
+	if got, want := lineString(st), "struct{*pkg.B; x.Z; a string; c int `c`; d string; e bool `e`}"; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if len(st.TagList) != 6 {
+		t.Errorf("got %d tags, want 6", len(st.TagList))
+	}
+
+	// move tagged fields first: the TagList shrinks
+	tagged := func(f *Field) bool { return fieldName(f) == "c" || fieldName(f) == "e" }
+	SortFields(st, func(a, b *Field) bool { return tagged(a) && !tagged(b) })
+	if got, want := lineString(st), "struct{c int `c`; e bool `e`; *pkg.B; x.Z; a, d string}"; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if len(st.TagList) != 2 {
+		t.Errorf("got %d tags, want 2", len(st.TagList))
+	}
+}
+
+func TestDuplicateFields(t *testing.T) {
+	const src = "package p; type S struct { a, b int; T; _, _ int; c string; a bool; *pkg.T; x.U; U[int]; b, d int; V }"
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, group := range DuplicateFields(typeExpr(t, f, "S").(*StructType)) {
+		got = append(got, fieldStrings(group))
+	}
+	const want = "a int; a bool | b int; b int | T; *pkg.T | x.U; U[int]"
+	if got := strings.Join(got, " | "); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestSortCases(t *testing.T) {
+	const src = `package p; func _() { switch x { case 3: a(); default: d(); case 1, 2: b() } }`
+	f := mustParse(t, src, 0)
+	var sw *SwitchStmt
+	Inspect(f, func(n Node) bool {
+		if s, ok := n.(*SwitchStmt); ok {
+			sw = s
+		}
+		return sw == nil
+	})
+
+	// default clause last, others by their case expressions
+	SortCases(sw, func(a, b *CaseClause) bool {
+		if a.Cases == nil || b.Cases == nil {
+			return b.Cases == nil && a.Cases != nil
+		}
+		return String(a.Cases) < String(b.Cases)
+	})
+	var got []string
+	for _, c := range sw.Body {
+		got = append(got, String(c.Body[0]))
+	}
+	if got, want := strings.Join(got, " "), "b() a() d()"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestParamCount(t *testing.T) {
+	const src = `package p
+
+func f0()
+func f1(int)
+func f3(a, b, c int)
+func f4(a, b int, s string, rest ...any)
+func (r T) m2(x, _ int)
+func f2[P any](P, ...P)
+`
+	f := mustParse(t, src, 0)
+	for _, d := range f.DeclList {
+		fn := d.(*FuncDecl)
+		want := int(fn.Name.Value[len(fn.Name.Value)-1] - '0')
+		if got := ParamCount(fn); got != want {
+			t.Errorf("%s: got %d parameters, want %d", fn.Name.Value, got, want)
+		}
+	}
+}
+
+func TestResultCount(t *testing.T) {
+	const src = `package p
+
+func f0_()
+func f1_() int
+func f0e() error
+func f2e() (x, y int, err error)
+func f1e() (int, *os.PathError)
+func f1x() (int, net.Error)
+func f2_() (error, int)
+func f3_() (a, b, c string)
+func f1y() (n int, err E)
+func f2z() (int, errors)
+`
+	f := mustParse(t, src, 0)
+	for _, d := range f.DeclList {
+		fn := d.(*FuncDecl)
+		name := fn.Name.Value
+		values, hasError := ResultCount(fn)
+		if want := int(name[1] - '0'); values != want {
+			t.Errorf("%s: got %d values, want %d", name, values, want)
+		}
+		if want := name[2] != '_' && name[2] != 'z'; hasError != want {
+			t.Errorf("%s: got hasError = %v, want %v", name, hasError, want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements structural fingerprints of syntax trees.
+
+package syntax
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strconv"
+)
+
+// Fingerprint returns a hex-encoded SHA-256 digest of the structure of
+// file. The digest is computed from a canonical serialization of the
+// tree which includes the kind of each node and all its attributes
+// (such as identifier names, literal values, and operators) but no
+// positions, comments, or pragmas. Declarations are serialized in source
+// order, since their order may be significant (for instance, for package
+// initialization). Thus two files which differ only in formatting and
+// comments have the same fingerprint, while (for instance) redundant
+// parentheses or reordered declarations change it.
+func Fingerprint(file *File) string {
+	h := sha256.New()
+	w := bufio.NewWriter(h)
+	e := fingerprinter{w: w, groups: make(map[*Group]int)}
+	e.value(reflect.ValueOf(file))
+	w.Flush()
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type fingerprinter struct {
+	w      *bufio.Writer
+	groups map[*Group]int // group -> group number, in source order
+}
+
+var (
+	posType      = reflect.TypeFor[Pos]()
+	pragmaType   = reflect.TypeFor[Pragma]()
+	commentsType = reflect.TypeFor[[]*Comment]()
+)
+
+// value writes the serialization of v, which is a (possibly nil)
+// node or a value contained in a node.
+func (e *fingerprinter) value(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			e.w.WriteString("nil")
+			return
+		}
+		if g, ok := v.Interface().(*Group); ok {
+			k, ok := e.groups[g]
+			if !ok {
+				k = len(e.groups)
+				e.groups[g] = k
+			}
+			e.w.WriteString("group" + strconv.Itoa(k))
+			return
+		}
+		e.node(v.Elem())
+
+	case reflect.Interface:
+		if v.IsNil() {
+			e.w.WriteString("nil")
+			return
+		}
+		e.value(v.Elem())
+
+	case reflect.Slice, reflect.Array:
+		e.w.WriteString("[")
+		for i := range v.Len() {
+			if i > 0 {
+				e.w.WriteString(" ")
+			}
+			e.value(v.Index(i))
+		}
+		e.w.WriteString("]")
+
+	case reflect.String:
+		e.w.WriteString(strconv.Quote(v.String()))
+
+	case reflect.Bool:
+		e.w.WriteString(strconv.FormatBool(v.Bool()))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		e.w.WriteString(strconv.FormatUint(v.Uint(), 10))
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.w.WriteString(strconv.FormatInt(v.Int(), 10))
+
+	default:
+		panic("internal error: unexpected value of type " + v.Type().String())
+	}
+}
+
+// node writes the serialization of the node struct x: its kind
+// followed by its structural fields.
+func (e *fingerprinter) node(x reflect.Value) {
+	t := x.Type()
+	e.w.WriteString("(" + t.Name())
+	for i := range t.NumField() {
+		if !isStructural(t, t.Field(i)) {
+			continue
+		}
+		e.w.WriteString(" ")
+		e.value(x.Field(i))
+	}
+	e.w.WriteString(")")
+}
+
+// isStructural reports whether the field f of the node struct type t
+// is part of the structure of the syntax tree: f is exported and not a
+// position, comment list, pragma, or branch statement target (which is
+// determined by the branch statement label).
+func isStructural(t reflect.Type, f reflect.StructField) bool {
+	return f.IsExported() && f.Type != posType && f.Type != pragmaType && f.Type != commentsType &&
+		!(t == branchStmtType && f.Name == "Target")
+}
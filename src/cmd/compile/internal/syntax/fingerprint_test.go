@@ -0,0 +1,64 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	const src = `package p
+
+// T is a type.
+type T struct {
+	x, y int ` + "`json:\"x\"`" + `
+}
+
+var (
+	a = 1
+	b = "b"
+)
+
+func (t *T) m() int {
+	if t.x > 0 { // comment
+		return t.x
+	}
+L:
+	for {
+		break L
+	}
+	return 0
+}
+`
+	const same = `package p
+type T struct{ x, y int ` + "`json:\"x\"`" + ` }
+var ( a = 1; b = "b" )
+/* comment */ func (t *T) m() int { if t.x > 0 { return t.x }; L: for { break L }; return 0 }
+`
+	want := Fingerprint(mustParse(t, src, KeepComments))
+	if len(want) != 64 {
+		t.Fatalf("got fingerprint %q, want 64 hex digits", want)
+	}
+	if got := Fingerprint(mustParse(t, same, 0)); got != want {
+		t.Errorf("reformatted file: got fingerprint %s, want %s", got, want)
+	}
+
+	for _, diff := range []string{
+		`package q`,
+		`package p; var a = 1`,
+		`package p; var a = 0x1`,
+		`package p; var (a = 1); var (b = "b")`,
+		`package p; var a, b = 1, "b"`,
+		`package p; var (b = "b"; a = 1)`,
+		`package p; func f() { g(x) }`,
+		`package p; func f() { g((x)) }`,
+		`package p; func f() { g(x...) }`,
+		`package p; func f() { x++ }`,
+		`package p; func f() { x-- }`,
+	} {
+		got := Fingerprint(mustParse(t, diff, 0))
+		if got == want {
+			t.Errorf("%s: got same fingerprint as original", diff)
+		}
+	}
+}
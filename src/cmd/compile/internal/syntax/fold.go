@@ -0,0 +1,233 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements constant folding of syntax trees.
+
+package syntax
+
+import (
+	"go/constant"
+	gotoken "go/token"
+	"strconv"
+)
+
+// FoldConstants replaces each operation in the tree rooted at root
+// whose operands are (recursively) integer or string literals, or the
+// boolean constants true and false, with the literal (or true or false)
+// representing the result of the operation, and returns the number of
+// operations folded. Integer operations are folded only if the operands
+// and the result fit into an int64; division by zero, operations on
+// mixed types, and operations involving other kinds of literals are not
+// folded. The identifiers true and false are assumed to denote the
+// predeclared constants.
+func FoldConstants(root Node) int {
+	count := 0
+	WalkAndChange(root, func(n *Node) bool {
+		if n == nil {
+			return true
+		}
+		op, ok := (*n).(*Operation)
+		if !ok || op.Op == Sub && op.Y == nil && isLit(op.X) {
+			return true // nothing to fold, or negative literal
+		}
+		v, k := evalConst(op)
+		if v == nil {
+			return true
+		}
+		*n = constExpr(StartPos(op), v)
+		count += k
+		return true
+	})
+	return count
+}
+
+// evalConst returns the constant value of x and the number of
+// operations evaluated if x is a foldable constant expression;
+// otherwise it returns a nil value.
+func evalConst(x Expr) (constant.Value, int) {
+	switch x := Unparen(x).(type) {
+	case *BasicLit:
+		if x.Bad || x.Kind != IntLit && x.Kind != StringLit {
+			return nil, 0
+		}
+		tok := gotoken.INT
+		if x.Kind == StringLit {
+			tok = gotoken.STRING
+		}
+		v := constant.MakeFromLiteral(x.Value, tok, 0)
+		if !fitsInt64(v) {
+			return nil, 0
+		}
+		return v, 0
+
+	case *Name:
+		switch x.Value {
+		case "true":
+			return constant.MakeBool(true), 0
+		case "false":
+			return constant.MakeBool(false), 0
+		}
+
+	case *Operation:
+		if x.Y == nil {
+			v, k := evalConst(x.X)
+			if v == nil {
+				return nil, 0
+			}
+			switch {
+			case x.Op == Not && v.Kind() == constant.Bool,
+				(x.Op == Add || x.Op == Sub || x.Op == Xor) && v.Kind() == constant.Int:
+				// ok
+			default:
+				return nil, 0
+			}
+			v = constant.UnaryOp(opToken[x.Op], v, 0)
+			if !fitsInt64(v) {
+				return nil, 0
+			}
+			return v, k + 1
+		}
+
+		v, kx := evalConst(x.X)
+		if v == nil {
+			return nil, 0
+		}
+		w, ky := evalConst(x.Y)
+		if w == nil || v.Kind() != w.Kind() && x.Op != Shl && x.Op != Shr {
+			return nil, 0
+		}
+		if r := foldBinary(x.Op, v, w); r != nil {
+			return r, kx + ky + 1
+		}
+	}
+
+	return nil, 0
+}
+
+// foldBinary returns the result of the binary operation v op w,
+// or nil if the operation cannot be folded.
+func foldBinary(op Operator, v, w constant.Value) constant.Value {
+	switch op {
+	case Eql, Neq, Lss, Leq, Gtr, Geq:
+		if v.Kind() == constant.Bool && op != Eql && op != Neq {
+			return nil
+		}
+		return constant.MakeBool(constant.Compare(v, opToken[op], w))
+	}
+
+	switch v.Kind() {
+	case constant.Bool:
+		if op != AndAnd && op != OrOr {
+			return nil
+		}
+		return constant.BinaryOp(v, opToken[op], w)
+
+	case constant.String:
+		if op != Add {
+			return nil
+		}
+		return constant.BinaryOp(v, gotoken.ADD, w)
+
+	case constant.Int:
+		var r constant.Value
+		switch op {
+		case Add, Sub, Mul, Or, Xor, And, AndNot:
+			r = constant.BinaryOp(v, opToken[op], w)
+		case Div, Rem:
+			if constant.Sign(w) == 0 {
+				return nil
+			}
+			tok := gotoken.QUO_ASSIGN // integer division
+			if op == Rem {
+				tok = gotoken.REM
+			}
+			r = constant.BinaryOp(v, tok, w)
+		case Shl, Shr:
+			if w.Kind() != constant.Int {
+				return nil
+			}
+			s, ok := constant.Uint64Val(w)
+			if !ok || s >= 64 {
+				return nil
+			}
+			r = constant.Shift(v, opToken[op], uint(s))
+		default:
+			return nil
+		}
+		if !fitsInt64(r) {
+			return nil
+		}
+		return r
+	}
+
+	return nil
+}
+
+// fitsInt64 reports whether v is not an integer or
+// an integer representable as an int64.
+func fitsInt64(v constant.Value) bool {
+	if v.Kind() != constant.Int {
+		return true
+	}
+	_, exact := constant.Int64Val(v)
+	return exact
+}
+
+// constExpr returns the expression denoting the constant value v,
+// which must be a boolean, integer, or string, at position pos.
+func constExpr(pos Pos, v constant.Value) Expr {
+	switch v.Kind() {
+	case constant.Bool:
+		return NewName(pos, strconv.FormatBool(constant.BoolVal(v)))
+	case constant.String:
+		return newBasicLit(pos, strconv.Quote(constant.StringVal(v)), StringLit)
+	default:
+		if constant.Sign(v) < 0 {
+			x := new(Operation)
+			x.pos = pos
+			x.Op = Sub
+			x.X = newBasicLit(pos, constant.UnaryOp(gotoken.SUB, v, 0).ExactString(), IntLit)
+			return x
+		}
+		return newBasicLit(pos, v.ExactString(), IntLit)
+	}
+}
+
+// isLit reports whether x is a basic literal.
+func isLit(x Expr) bool {
+	_, ok := x.(*BasicLit)
+	return ok
+}
+
+func newBasicLit(pos Pos, value string, kind LitKind) *BasicLit {
+	x := new(BasicLit)
+	x.pos = pos
+	x.Value = value
+	x.Kind = kind
+	return x
+}
+
+// opToken maps syntax operators to the corresponding go/token tokens.
+var opToken = [...]gotoken.Token{
+	Not:    gotoken.NOT,
+	OrOr:   gotoken.LOR,
+	AndAnd: gotoken.LAND,
+	Eql:    gotoken.EQL,
+	Neq:    gotoken.NEQ,
+	Lss:    gotoken.LSS,
+	Leq:    gotoken.LEQ,
+	Gtr:    gotoken.GTR,
+	Geq:    gotoken.GEQ,
+	Add:    gotoken.ADD,
+	Sub:    gotoken.SUB,
+	Or:     gotoken.OR,
+	Xor:    gotoken.XOR,
+	Mul:    gotoken.MUL,
+	Div:    gotoken.QUO,
+	Rem:    gotoken.REM,
+	And:    gotoken.AND,
+	AndNot: gotoken.AND_NOT,
+	Shl:    gotoken.SHL,
+	Shr:    gotoken.SHR,
+}
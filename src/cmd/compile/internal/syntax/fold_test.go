@@ -0,0 +1,44 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import "testing"
+
+func TestFoldConstants(t *testing.T) {
+	for _, test := range []struct {
+		x, want string
+		count   int
+	}{
+		{"2 + 3*4", "14", 2},
+		{"(2 + 3) * 4", "20", 2},
+		{"1 - 10", "-9", 1},
+		{"-1", "-1", 0},
+		{"-(1 + 2)", "-3", 2},
+		{"7 / 2", "3", 1},
+		{"7 % 2", "1", 1},
+		{"1 << 10", "1024", 1},
+		{"0x10 | 0b1", "17", 1},
+		{`"a" + "b" + "c"`, `"abc"`, 2},
+		{"true && !false", "true", 2},
+		{"1 < 2 || false", "true", 2},
+		{`"a" == "b"`, "false", 1},
+		{"x + 2*3", "x + 6", 1},
+
+		// not folded
+		{"1 / 0", "1 / 0", 0},
+		{"1 << 64", "1 << 64", 0},
+		{"9223372036854775807 + 1", "9223372036854775807 + 1", 0},
+		{"99999999999999999999 - 1", "99999999999999999999 - 1", 0},
+		{`1 + "a"`, `1 + "a"`, 0},
+		{"1.5 + 2", "1.5 + 2", 0},
+		{"'a' + 1", "'a' + 1", 0},
+		{"true + false", "true + false", 0},
+		{"x + y", "x + y", 0},
+	} {
+		src := "package p; var _ = " + test.x
+		want := "package p; var _ = " + test.want
+		testRewrite(t, src, want, test.count, func(f *File) int { return FoldConstants(f) })
+	}
+}
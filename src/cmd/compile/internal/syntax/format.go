@@ -0,0 +1,158 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the extraction of format strings
+// and their verbs from calls of printf-like functions.
+
+package syntax
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A FormatCall describes a call of a printf-like function.
+type FormatCall struct {
+	Call   *CallExpr
+	Format *BasicLit    // format string literal, or nil
+	Verbs  []FormatVerb // verbs of the format string, in order
+	Args   []Expr       // arguments following the format string
+}
+
+// A FormatVerb describes a formatting directive of a format string,
+// which has the form %[flags][width][.prec]verb; the width and precision
+// may be * (to be taken from an argument), and they as well as the verb
+// may be preceded by an explicit argument index [n].
+type FormatVerb struct {
+	Offset int    // byte offset of the % in the (unquoted) format string
+	Text   string // the directive, such as %-8.2f or %[2]*d
+	Flags  string // flags, such as "-" or "+#"
+	Width  string // width, such as "8", "*", or "[1]*", or ""
+	Prec   string // precision (without the .), such as "2" or "*", or ""
+	Index  int    // explicit argument index n of the operand of the verb, or 0
+	Verb   rune   // verb, such as 'd'; 0 if the directive is incomplete
+}
+
+// FormatCalls returns the calls of printf-like functions in the tree
+// rooted at root, in source order. A call is printf-like if its function
+// is an identifier or a selector expression, such as logf, fmt.Printf, or
+// t.Errorf, for whose source text printfLike returns true. The format
+// argument of a call is its first argument if that is a string literal,
+// and its second argument otherwise, such as that of fmt.Fprintf(w, "%d",
+// x); the verbs of the format string are parsed, and the arguments
+// following it become the Args of the FormatCall. If the format argument
+// is not a string literal, as in fmt.Printf(format, x), the call is
+// reported without format, verbs, and arguments. Note that a string
+// literal following a non-literal format, as in logf(format, "x"), is
+// misidentified as the format, and that a literal %% is not a verb.
+func FormatCalls(root Node, printfLike func(sel string) bool) []FormatCall {
+	var list []FormatCall
+	Inspect(root, func(n Node) bool {
+		call, ok := n.(*CallExpr)
+		if !ok {
+			return true
+		}
+		switch fun := Unparen(call.Fun).(type) {
+		case *Name, *SelectorExpr:
+			if !printfLike(String(fun)) {
+				return true
+			}
+		default:
+			return true
+		}
+
+		fc := FormatCall{Call: call}
+		if i := formatIndex(call.ArgList); i >= 0 {
+			lit := Unparen(call.ArgList[i]).(*BasicLit)
+			if format, err := strconv.Unquote(lit.Value); err == nil {
+				fc.Format = lit
+				fc.Verbs = parseFormat(format)
+				fc.Args = call.ArgList[i+1:]
+			}
+		}
+		list = append(list, fc)
+		return true
+	})
+	return list
+}
+
+// formatIndex returns the index of the format argument in args: 0 if the
+// first argument is a string literal, 1 if the second one is, or -1.
+func formatIndex(args []Expr) int {
+	for i := 0; i < len(args) && i < 2; i++ {
+		if lit, ok := Unparen(args[i]).(*BasicLit); ok && lit.Kind == StringLit && !lit.Bad {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseFormat returns the verbs of the format string format.
+func parseFormat(format string) []FormatVerb {
+	var list []FormatVerb
+	for i := 0; i < len(format); {
+		if format[i] != '%' {
+			i++
+			continue
+		}
+		v := FormatVerb{Offset: i}
+		i++
+
+		// index parses an optional argument index [n].
+		index := func() int {
+			if i >= len(format) || format[i] != '[' {
+				return 0
+			}
+			j := strings.IndexByte(format[i:], ']')
+			if j < 0 {
+				return 0
+			}
+			n, err := strconv.Atoi(format[i+1 : i+j])
+			if err != nil {
+				return 0
+			}
+			i += j + 1
+			return n
+		}
+
+		// number parses a width or precision: a possibly
+		// indexed *, or a (possibly empty) decimal number.
+		number := func() string {
+			start := i
+			index()
+			if i < len(format) && format[i] == '*' {
+				i++
+				return format[start:i]
+			}
+			i = start
+			for i < len(format) && '0' <= format[i] && format[i] <= '9' {
+				i++
+			}
+			return format[start:i]
+		}
+
+		start := i
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		v.Flags = format[start:i]
+		v.Width = number()
+		if i < len(format) && format[i] == '.' {
+			i++
+			v.Prec = number()
+		}
+		v.Index = index()
+		if i < len(format) {
+			r, size := utf8.DecodeRuneInString(format[i:])
+			v.Verb = r
+			i += size
+		}
+		v.Text = format[v.Offset:i]
+		if v.Verb != '%' {
+			list = append(list, v)
+		}
+	}
+	return list
+}
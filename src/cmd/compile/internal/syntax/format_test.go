@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFormatCalls(t *testing.T) {
+	const src = `package p
+
+func _() {
+	fmt.Printf("%d items: %v\n", n, items)
+	fmt.Fprintf(w, "%-8s|%6.2f%%", name, x)
+	fmt.Println("%d", n)
+	t.Errorf(` + "`got %q, want %q`" + `, got, want)
+	logf(format, args...)
+	logf(format, n, "%d")
+	p.errorf(pos, "%s: %v", name, err)
+	fmt.Sprintf("%[2]*[1]d %.*f %", 1, 2, 3, 4)
+	g()("%d", 1)
+}
+`
+	printfLike := func(sel string) bool {
+		return strings.HasSuffix(sel, "f")
+	}
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, c := range FormatCalls(f, printfLike) {
+		var verbs []string
+		for _, v := range c.Verbs {
+			verbs = append(verbs, fmt.Sprintf("%d:%s", v.Offset, v.Text))
+		}
+		var format string
+		if c.Format != nil {
+			format = c.Format.Value
+		}
+		got = append(got, fmt.Sprintf("%s %s [%s] (%s)", String(c.Call.Fun), format, strings.Join(verbs, " "), nodeStrings(c.Args)))
+	}
+	want := strings.Join([]string{
+		`fmt.Printf "%d items: %v\n" [0:%d 10:%v] (n; items)`,
+		`fmt.Fprintf "%-8s|%6.2f%%" [0:%-8s 5:%6.2f] (name; x)`,
+		"t.Errorf `got %q, want %q` [4:%q 13:%q] (got; want)",
+		"logf  [] ()",
+		"logf  [] ()",
+		`p.errorf "%s: %v" [0:%s 4:%v] (name; err)`,
+		`fmt.Sprintf "%[2]*[1]d %.*f %" [0:%[2]*[1]d 10:%.*f 15:%] (1; 2; 3; 4)`,
+	}, "\n")
+	if got := strings.Join(got, "\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, test := range []struct {
+		format string
+		want   FormatVerb
+	}{
+		{"%d", FormatVerb{Text: "%d", Verb: 'd'}},
+		{"%+#08.3e", FormatVerb{Text: "%+#08.3e", Flags: "+#0", Width: "8", Prec: "3", Verb: 'e'}},
+		{"%[3]*.[2]*[1]f", FormatVerb{Text: "%[3]*.[2]*[1]f", Width: "[3]*", Prec: "[2]*", Index: 1, Verb: 'f'}},
+		{"%.f", FormatVerb{Text: "%.f", Verb: 'f'}},
+		{"%[2]v", FormatVerb{Text: "%[2]v", Index: 2, Verb: 'v'}},
+		{"%x%", FormatVerb{Text: "%x", Verb: 'x'}},
+		{"%ä", FormatVerb{Text: "%ä", Verb: 'ä'}},
+		{"%-", FormatVerb{Text: "%-", Flags: "-"}},
+	} {
+		verbs := parseFormat(test.format)
+		if len(verbs) == 0 || verbs[0] != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.format, verbs, test.want)
+		}
+	}
+}
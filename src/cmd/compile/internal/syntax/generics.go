@@ -0,0 +1,128 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements helper functions for generic
+// declarations and their instantiations.
+
+package syntax
+
+// TypeParams returns the type parameters of decl if decl is a
+// generic function or type declaration; otherwise it returns nil.
+func TypeParams(decl Decl) []*Field {
+	switch d := decl.(type) {
+	case *FuncDecl:
+		return d.TParamList
+	case *TypeDecl:
+		return d.TParamList
+	}
+	return nil
+}
+
+// Instantiations returns the index expressions in the tree rooted at
+// root which denote instantiations of generic types or functions, such
+// as List[int] or Map[K, V], in source order. Without type information,
+// an index expression x[i] is recognized as an instantiation if
+//
+//   - it appears in a type context (for instance, as the type of a
+//     variable, field, or parameter, of a composite literal, or in a
+//     type assertion or type switch case), or as a type argument of
+//     another instantiation;
+//   - it has multiple indices, as in x[a, b];
+//   - the index is a type literal, as in x[[]int] or x[map[K]V]; or
+//   - x is the name of a generic type or function declared in root.
+//
+// Other instantiations, such as those of imported generic functions
+// with a single type argument denoted by a type name (pkg.F[int]),
+// cannot be distinguished from ordinary index expressions and are
+// not reported.
+func Instantiations(root Node) []*IndexExpr {
+	generic := make(map[string]bool)
+	Inspect(root, func(n Node) bool {
+		if d, ok := n.(Decl); ok && len(TypeParams(d)) > 0 {
+			switch d := d.(type) {
+			case *FuncDecl:
+				if d.Recv == nil {
+					generic[d.Name.Value] = true
+				}
+			case *TypeDecl:
+				generic[d.Name.Value] = true
+			}
+		}
+		return true
+	})
+
+	// types is the set of expressions in a type context;
+	// it is populated in pre-order, before the expressions
+	// themselves are visited
+	types := make(map[Expr]bool)
+	typ := func(list ...Expr) {
+		for _, x := range list {
+			if x != nil {
+				types[x] = true
+			}
+		}
+	}
+
+	var list []*IndexExpr
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *ConstDecl:
+			typ(n.Type)
+		case *TypeDecl:
+			typ(n.Type)
+		case *VarDecl:
+			typ(n.Type)
+		case *CompositeLit:
+			typ(n.Type)
+		case *AssertExpr:
+			typ(n.Type)
+		case *SwitchStmt:
+			if _, ok := n.Tag.(*TypeSwitchGuard); ok {
+				for _, c := range n.Body {
+					typ(UnpackListExpr(c.Cases)...)
+				}
+			}
+		case *Field:
+			typ(n.Type)
+		case *ArrayType:
+			typ(n.Elem)
+		case *SliceType:
+			typ(n.Elem)
+		case *DotsType:
+			typ(n.Elem)
+		case *MapType:
+			typ(n.Key, n.Value)
+		case *ChanType:
+			typ(n.Elem)
+		case *ParenExpr:
+			if types[n] {
+				typ(n.X)
+			}
+		case *Operation:
+			if types[n] && n.Op == Mul && n.Y == nil {
+				typ(n.X) // pointer base type
+			}
+		case *IndexExpr:
+			if types[n] || isInstance(n, generic) {
+				typ(UnpackListExpr(n.Index)...) // type arguments
+				list = append(list, n)
+			}
+		}
+		return true
+	})
+
+	return list
+}
+
+// isInstance reports whether the index expression x, not in a type
+// context, is syntactically an instantiation, given the set of names
+// of generic types and functions.
+func isInstance(x *IndexExpr, generic map[string]bool) bool {
+	switch Unparen(x.Index).(type) {
+	case *ListExpr, *ArrayType, *SliceType, *MapType, *ChanType, *FuncType, *StructType, *InterfaceType:
+		return true
+	}
+	name, ok := Unparen(x.X).(*Name)
+	return ok && generic[name.Value]
+}
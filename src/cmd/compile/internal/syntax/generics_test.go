@@ -0,0 +1,56 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import "testing"
+
+func TestTypeParams(t *testing.T) {
+	const src = `package p
+
+type T[P any, Q ~int] struct{}
+type U int
+func f[P any](x P)
+func g()
+var v int
+`
+	f := mustParse(t, src, 0)
+	want := []string{"P any; Q ~int", "", "P any", "", ""}
+	for i, d := range f.DeclList {
+		if got := fieldStrings(TypeParams(d)); got != want[i] {
+			t.Errorf("%s: got %q, want %q", String(d), got, want[i])
+		}
+	}
+}
+
+func TestInstantiations(t *testing.T) {
+	const src = `package p
+
+type List[E any] []E
+
+func Map[K comparable, V any](m map[K]V) {}
+
+func _(a []int, m map[string]int, l List[int]) {
+	var _ pkg.Set[string]
+	_ = a[0]
+	_ = m["k"]
+	_ = List[string]{}
+	_ = Map[string, int]
+	_ = Map[string]
+	_ = pkg.F[int]
+	_ = pkg.G[[]int]
+	_ = x.(*pkg.Opt[int])
+	_ = new(List[pkg.Pair[int, int]])
+	switch x.(type) {
+	case pkg.Set[int]:
+	}
+}
+`
+	f := mustParse(t, src, 0)
+	got := nodeStrings(Instantiations(f))
+	const want = "List[int]; pkg.Set[string]; List[string]; Map[string, int]; Map[string]; pkg.G[[]int]; pkg.Opt[int]; List[pkg.Pair[int, int]]; pkg.Pair[int, int]; pkg.Set[int]"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements helper functions for import declarations.
+
+package syntax
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ImportPath returns the unquoted import path of d.
+// The result is the empty string if the path is missing or invalid.
+func ImportPath(d *ImportDecl) string {
+	if d.Path == nil || d.Path.Bad || d.Path.Kind != StringLit {
+		return ""
+	}
+	path, err := strconv.Unquote(d.Path.Value)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// DuplicateImports returns the import declarations of file which
+// import a path already imported by a preceding declaration, in
+// source order, independent of whether they rename the imported
+// package. For instance, for
+//
+//	import (
+//		"fmt"
+//		f "fmt"
+//		`fmt`
+//	)
+//
+// the second and third declaration are returned. Paths are compared
+// after unquoting; declarations with an invalid path are ignored.
+func DuplicateImports(file *File) []*ImportDecl {
+	var list []*ImportDecl
+	seen := make(map[string]bool)
+	for _, d := range file.DeclList {
+		d, ok := d.(*ImportDecl)
+		if !ok {
+			continue
+		}
+		path := ImportPath(d)
+		if path == "" {
+			continue
+		}
+		if seen[path] {
+			list = append(list, d)
+		}
+		seen[path] = true
+	}
+	return list
+}
+
+// BlankImports returns the import declarations of file which import
+// a package for its side effects only, as in import _ "embed", in
+// source order. Use ImportPath to obtain the (unquoted) import path.
+func BlankImports(file *File) []*ImportDecl {
+	return importsNamed(file, "_")
+}
+
+// DotImports returns the import declarations of file which import the
+// exported identifiers of a package into the file block, as in
+// import . "math", in source order.
+func DotImports(file *File) []*ImportDecl {
+	return importsNamed(file, ".")
+}
+
+// importsNamed returns the import declarations of file
+// with the local package name name, in source order.
+func importsNamed(file *File, name string) []*ImportDecl {
+	var list []*ImportDecl
+	for _, d := range file.DeclList {
+		if d, ok := d.(*ImportDecl); ok && d.LocalPkgName != nil && d.LocalPkgName.Value == name {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+// OrganizeImports sorts the import declarations at the start of file
+// into two sections, the imports of standard library packages (as
+// reported by isStdlib) followed by all other imports, and sorts each
+// section by import path. Imports with the same path keep their relative
+// order; imports with an invalid path are moved to the end. Renamed,
+// blank, and dot imports are sorted by their path like any other import.
+// If any of the import declarations is grouped (as in import (...)),
+// each non-empty section becomes a group of its own; otherwise the
+// declarations remain ungrouped. OrganizeImports reports whether file
+// was changed.
+func OrganizeImports(file *File, isStdlib func(path string) bool) bool {
+	n := 0
+	grouped := false
+	for _, d := range file.DeclList {
+		d, ok := d.(*ImportDecl)
+		if !ok {
+			break
+		}
+		grouped = grouped || d.Group != nil
+		n++
+	}
+	imports := file.DeclList[:n]
+
+	section := func(d Decl) int {
+		switch path := ImportPath(d.(*ImportDecl)); {
+		case path == "":
+			return 2
+		case isStdlib(path):
+			return 0
+		}
+		return 1
+	}
+	old := slices.Clone(imports)
+	slices.SortStableFunc(imports, func(a, b Decl) int {
+		if c := section(a) - section(b); c != 0 {
+			return c
+		}
+		return strings.Compare(ImportPath(a.(*ImportDecl)), ImportPath(b.(*ImportDecl)))
+	})
+	changed := !slices.Equal(old, imports)
+	if !grouped {
+		return changed
+	}
+
+	// Assign the groups, reusing the existing group of the first
+	// declaration of a section if that group is not yet in use.
+	groups := make([]*Group, n)
+	used := make(map[*Group]bool)
+	for i, d := range imports {
+		d := d.(*ImportDecl)
+		if i > 0 && min(section(d), 1) == min(section(imports[i-1]), 1) {
+			groups[i] = groups[i-1] // invalid paths belong to the last section
+		} else if d.Group != nil && !used[d.Group] {
+			groups[i] = d.Group
+		} else {
+			groups[i] = new(Group)
+		}
+		used[groups[i]] = true
+		if d.Group != groups[i] {
+			changed = true
+			d.Group = groups[i]
+		}
+	}
+	return changed
+}
+
+// A QualifiedRef describes a reference pkg.Symbol to
+// an exported or unexported object of an imported package.
+type QualifiedRef struct {
+	Package string // package name, as used in the reference
+	Symbol  string // name of the referenced object
+	Pos     Pos    // position of the reference (of the package name)
+}
+
+// QualifiedRefs returns the qualified identifiers in file, in source
+// order: the selector expressions x.Sel where x is an identifier
+// contained in importedNames (the names under which packages are
+// imported by file). Selectors on local variables or parameters which
+// shadow an imported package name, such as fmt.x in
+//
+//	func f(fmt T) { _ = fmt.x }
+//
+// are not package-qualified and thus not included.
+func QualifiedRefs(file *File, importedNames map[string]bool) []QualifiedRef {
+	// Identifiers resolving to a local declaration
+	// do not denote packages.
+	local := make(map[*Name]bool)
+	r := resolver{
+		use: func(name, decl *Name) {
+			if decl != nil {
+				local[name] = true
+			}
+		},
+	}
+	r.open(file)
+	r.resolve(file)
+	r.close()
+
+	var list []QualifiedRef
+	Inspect(file, func(n Node) bool {
+		if sel, ok := n.(*SelectorExpr); ok {
+			if x, ok := sel.X.(*Name); ok && importedNames[x.Value] && !local[x] {
+				list = append(list, QualifiedRef{x.Value, sel.Sel.Value, x.Pos()})
+			}
+		}
+		return true
+	})
+	return list
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateImports(t *testing.T) {
+	const src = "package p; import (\"fmt\"; f \"fmt\"; \"os\"; `fmt`; _ \"os\"; \"io\")"
+	f := mustParse(t, src, 0)
+	got := nodeStrings(DuplicateImports(f))
+	const want = "f \"fmt\"; `fmt`; _ \"os\""
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestBlankAndDotImports(t *testing.T) {
+	const src = `package p
+
+import (
+	_ "embed"
+	. "math"
+	"fmt"
+	f "fmt"
+	_ ` + "`net/http/pprof`" + `
+)
+
+import . "strings"
+`
+	file := mustParse(t, src, 0)
+	paths := func(list []*ImportDecl) string {
+		var s []string
+		for _, d := range list {
+			s = append(s, ImportPath(d))
+		}
+		return strings.Join(s, " ")
+	}
+	if got, want := paths(BlankImports(file)), "embed net/http/pprof"; got != want {
+		t.Errorf("got blank imports %s, want %s", got, want)
+	}
+	if got, want := paths(DotImports(file)), "math strings"; got != want {
+		t.Errorf("got dot imports %s, want %s", got, want)
+	}
+}
+
+func TestOrganizeImports(t *testing.T) {
+	isStdlib := func(path string) bool { return !strings.Contains(path, ".") }
+	for _, test := range []struct {
+		src, want string
+		changed   bool
+	}{
+		{`package p; import "os"; import "fmt"`, `package p; import "fmt"; import "os"`, true},
+		{`package p; import "fmt"; import "os"`, `package p; import "fmt"; import "os"`, false},
+		{
+			`package p; import ( "fmt"; "os" ); import "example.com/a"`,
+			`package p; import ( "fmt"; "os" ); import ( "example.com/a" )`,
+			true,
+		},
+		{
+			`package p; import ( "example.com/b"; x "os"; _ "example.com/a"; . "fmt" ); var _ = 0`,
+			`package p; import ( . "fmt"; x "os" ); import ( _ "example.com/a"; "example.com/b" ); var _ = 0`,
+			true,
+		},
+		{
+			`package p; import ( "fmt"; "os" ); import ( "example.com/a"; "example.com/b" )`,
+			`package p; import ( "fmt"; "os" ); import ( "example.com/a"; "example.com/b" )`,
+			false,
+		},
+		{
+			`package p; import ( "fmt"; "example.com/a"; "os" )`,
+			`package p; import ( "fmt"; "os" ); import ( "example.com/a" )`,
+			true,
+		},
+		{
+			`package p; import ( "fmt"; "os"; "example.com/a" )`,
+			`package p; import ( "fmt"; "os" ); import ( "example.com/a" )`,
+			true,
+		},
+		{`package p; import ( "os"; "fmt"; f "fmt" )`, `package p; import ( "fmt"; f "fmt"; "os" )`, true},
+	} {
+		var changed bool
+		testRewrite(t, test.src, test.want, 0, func(f *File) int {
+			changed = OrganizeImports(f, isStdlib)
+			return 0
+		})
+		if changed != test.changed {
+			t.Errorf("%s: got changed = %v, want %v", test.src, changed, test.changed)
+		}
+	}
+}
+
+func TestQualifiedRefs(t *testing.T) {
+	const src = `package p
+
+import (
+	"fmt"
+	str "strings"
+)
+
+var _ = str.ToUpper
+
+type T struct{ x fmt.Stringer }
+
+func f(t T) {
+	fmt.Println(str.Repeat("x", 2), t.x)
+	var fmt T
+	_ = fmt.x
+	_ = func(str T) { _ = str.x }
+	_ = os.Args
+}
+`
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, r := range QualifiedRefs(f, map[string]bool{"fmt": true, "str": true}) {
+		got = append(got, fmt.Sprintf("%s.%s@%d:%d", r.Package, r.Symbol, r.Pos.Line(), r.Pos.Col()))
+	}
+	const want = "str.ToUpper@8:9 fmt.Stringer@10:18 fmt.Println@13:2 str.Repeat@13:14"
+	if got := strings.Join(got, " "); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
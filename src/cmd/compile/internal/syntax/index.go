@@ -0,0 +1,91 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements an index of the nodes of a syntax tree by kind.
+
+package syntax
+
+import "reflect"
+
+// A NodeIndex holds the nodes of a syntax tree, partitioned by kind.
+// It is built with a single traversal by CollectAll and serves analyses
+// that need the nodes of several kinds. All accessors return the nodes
+// of the respective kind in pre-order (the order in which Walk visits
+// them); nodes shared by multiple parents are included once.
+type NodeIndex struct {
+	kinds map[reflect.Type][]Node
+}
+
+// CollectAll returns the index of the nodes of the tree rooted at root.
+func CollectAll(root Node) *NodeIndex {
+	x := &NodeIndex{kinds: make(map[reflect.Type][]Node)}
+	seen := make(map[Node]bool)
+	Inspect(root, func(n Node) bool {
+		if n == nil || seen[n] {
+			return false
+		}
+		seen[n] = true
+		t := reflect.TypeOf(n)
+		x.kinds[t] = append(x.kinds[t], n)
+		return true
+	})
+	return x
+}
+
+// IndexedNodes returns the nodes of type N in the index x, for any
+// node type such as *CallExpr or *Name.
+func IndexedNodes[N Node](x *NodeIndex) []N {
+	nodes := x.kinds[reflect.TypeFor[N]()]
+	list := make([]N, len(nodes))
+	for i, n := range nodes {
+		list[i] = n.(N)
+	}
+	return list
+}
+
+// Len returns the total number of nodes in the index.
+func (x *NodeIndex) Len() int {
+	n := 0
+	for _, list := range x.kinds {
+		n += len(list)
+	}
+	return n
+}
+
+// Calls returns the call expressions.
+func (x *NodeIndex) Calls() []*CallExpr { return IndexedNodes[*CallExpr](x) }
+
+// Funcs returns the function declarations.
+func (x *NodeIndex) Funcs() []*FuncDecl { return IndexedNodes[*FuncDecl](x) }
+
+// FuncLits returns the function literals.
+func (x *NodeIndex) FuncLits() []*FuncLit { return IndexedNodes[*FuncLit](x) }
+
+// Idents returns the identifiers.
+func (x *NodeIndex) Idents() []*Name { return IndexedNodes[*Name](x) }
+
+// Literals returns the basic literals.
+func (x *NodeIndex) Literals() []*BasicLit { return IndexedNodes[*BasicLit](x) }
+
+// Selectors returns the selector expressions.
+func (x *NodeIndex) Selectors() []*SelectorExpr { return IndexedNodes[*SelectorExpr](x) }
+
+// Assignments returns the assignment statements, including short
+// variable declarations and increment and decrement statements.
+func (x *NodeIndex) Assignments() []*AssignStmt { return IndexedNodes[*AssignStmt](x) }
+
+// Returns returns the return statements.
+func (x *NodeIndex) Returns() []*ReturnStmt { return IndexedNodes[*ReturnStmt](x) }
+
+// Ifs returns the if statements.
+func (x *NodeIndex) Ifs() []*IfStmt { return IndexedNodes[*IfStmt](x) }
+
+// Loops returns the for statements, including those with range clauses.
+func (x *NodeIndex) Loops() []*ForStmt { return IndexedNodes[*ForStmt](x) }
+
+// Switches returns the switch statements, including type switches.
+func (x *NodeIndex) Switches() []*SwitchStmt { return IndexedNodes[*SwitchStmt](x) }
+
+// Imports returns the import declarations.
+func (x *NodeIndex) Imports() []*ImportDecl { return IndexedNodes[*ImportDecl](x) }
@@ -0,0 +1,68 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import "testing"
+
+func TestCollectAll(t *testing.T) {
+	const src = `package p
+
+import "fmt"
+
+type T struct{ a, b int }
+
+func f(x int) int {
+	if x > 0 {
+		fmt.Println(g(x))
+	}
+	for i := range x {
+		x += i
+	}
+	return func() int { return x }()
+}
+`
+	f := mustParse(t, src, 0)
+	x := CollectAll(f)
+
+	if got, want := nodeStrings(x.Calls()), "fmt.Println(g(x)); g(x); func() int {…}()"; got != want {
+		t.Errorf("calls: got %s, want %s", got, want)
+	}
+	if got, want := nodeStrings(x.Returns()), "return func() int {…}(); return x"; got != want {
+		t.Errorf("returns: got %s, want %s", got, want)
+	}
+	if got, want := nodeStrings(x.Imports()), `import "fmt"`; got != want {
+		t.Errorf("imports: got %s, want %s", got, want)
+	}
+	if n := len(x.Funcs()); n != 1 {
+		t.Errorf("got %d function declarations, want 1", n)
+	}
+	if n := len(IndexedNodes[*StructType](x)); n != 1 {
+		t.Errorf("got %d struct types, want 1", n)
+	}
+	if n := len(x.Switches()); n != 0 {
+		t.Errorf("got %d switch statements, want 0", n)
+	}
+
+	// the index agrees with a traversal, except for shared nodes
+	var names []*Name
+	count := 0
+	seen := make(map[Node]bool)
+	Inspect(f, func(n Node) bool {
+		if n != nil && !seen[n] {
+			seen[n] = true
+			count++
+			if n, ok := n.(*Name); ok {
+				names = append(names, n)
+			}
+		}
+		return true
+	})
+	if got, want := nodeStrings(x.Idents()), nodeStrings(names); got != want {
+		t.Errorf("idents: got %s, want %s", got, want)
+	}
+	if x.Len() != count {
+		t.Errorf("got %d nodes, want %d", x.Len(), count)
+	}
+}
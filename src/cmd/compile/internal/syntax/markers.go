@@ -0,0 +1,78 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the search for markers such as
+// TODO or FIXME in comments, string literals, and labels.
+
+package syntax
+
+import (
+	"sort"
+	"strings"
+)
+
+// A Marker describes an occurrence of a marker string.
+type Marker struct {
+	Pos    Pos    // position of the marker
+	Marker string // the marker found, such as "TODO"
+	Node   Node   // *Comment, *BasicLit (string literal), or *Name (label)
+}
+
+// FindMarkers returns the occurrences of the given markers (such as
+// "TODO" or "FIXME") in the string literals and labels of the tree
+// rooted at root and, if root is a *File parsed in KeepComments mode,
+// its comments, in source order. Markers are matched as substrings,
+// case-sensitively; overlapping occurrences of different markers are
+// all reported.
+func FindMarkers(root Node, markers []string) []Marker {
+	var list []Marker
+	find := func(n Node, pos Pos, text string) {
+		start := len(list)
+		for _, m := range markers {
+			if m == "" {
+				continue
+			}
+			for i := 0; ; i++ {
+				j := strings.Index(text[i:], m)
+				if j < 0 {
+					break
+				}
+				i += j
+				list = append(list, Marker{offsetPos(pos, text, i), m, n})
+			}
+		}
+		found := list[start:]
+		sort.SliceStable(found, func(i, j int) bool {
+			return found[i].Pos.Cmp(found[j].Pos) < 0
+		})
+	}
+
+	WalkWithComments(root, inspector(func(n Node) bool {
+		switch n := n.(type) {
+		case *Comment:
+			find(n, n.Pos(), n.Text)
+		case *BasicLit:
+			if n.Kind == StringLit {
+				find(n, n.Pos(), n.Value)
+			}
+		case *LabeledStmt:
+			find(n.Label, n.Label.Pos(), n.Label.Value)
+		}
+		return true
+	}))
+
+	return list
+}
+
+// offsetPos returns the position of the byte at offset i of text,
+// which starts at pos.
+func offsetPos(pos Pos, text string, i int) Pos {
+	line, col := pos.Line(), pos.Col()
+	if k := strings.LastIndexByte(text[:i], '\n'); k >= 0 {
+		line += uint(strings.Count(text[:i], "\n"))
+		col = 1
+		i -= k + 1
+	}
+	return MakePos(pos.Base(), line, col+uint(i))
+}
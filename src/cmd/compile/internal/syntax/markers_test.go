@@ -0,0 +1,51 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFindMarkers(t *testing.T) {
+	const src = `package p
+
+// TODO(gri) first
+var s = "FIXME: not a comment"
+
+func f() {
+TODO_LATER:
+	for {
+		/* multi-line
+		   FIXME and TODO */
+		g(` + "`raw\n  TODO`" + `)
+	}
+} // TODO
+`
+	f := mustParse(t, src, KeepComments)
+	var got []string
+	for _, m := range FindMarkers(f, []string{"TODO", "FIXME"}) {
+		got = append(got, fmt.Sprintf("%d:%d %s %T", m.Pos.Line(), m.Pos.Col(), m.Marker, m.Node))
+	}
+	want := []string{
+		"3:4 TODO *syntax.Comment",
+		"4:10 FIXME *syntax.BasicLit",
+		"7:1 TODO *syntax.Name",
+		"10:6 FIXME *syntax.Comment",
+		"10:16 TODO *syntax.Comment",
+		"12:3 TODO *syntax.BasicLit",
+		"14:6 TODO *syntax.Comment",
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// without comments
+	f = mustParse(t, src, 0)
+	if got := len(FindMarkers(f, []string{"TODO", "FIXME"})); got != 3 {
+		t.Errorf("got %d markers without comments, want 3", got)
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements structural pattern matching and
+// pattern-based rewriting of syntax trees.
+
+package syntax
+
+import "reflect"
+
+// A Match describes a subtree matching a pattern.
+type Match struct {
+	Node     Node            // root of the matching subtree
+	Bindings map[string]Node // named wildcards and the expressions they matched
+}
+
+// FindMatches returns the subtrees of the tree rooted at root which
+// match pattern, in pre-order. A subtree matches if it is structurally
+// equal to pattern (see Equal), where the wildcards in pattern, that is,
+// identifiers consisting of an underscore optionally followed by a name
+// (such as _ or _x), match any expression. For named wildcards, the
+// matched expressions are recorded in the bindings of the match, keyed
+// by the wildcard name (such as "_x"); all occurrences of a named
+// wildcard must match structurally equal expressions. Thus, the pattern
+// _x == _x matches a == a but not a == b. Since wildcards are
+// identifiers, they cannot stand for statements or declarations, and
+// a blank identifier in pattern matches any expression as well.
+func FindMatches(root Node, pattern Node) []Match {
+	var list []Match
+	p := reflect.ValueOf(pattern)
+	Inspect(root, func(n Node) bool {
+		if n == nil {
+			return false
+		}
+		holes := make(map[string]Node)
+		if match(p, reflect.ValueOf(n), holes) {
+			if len(holes) == 0 {
+				holes = nil
+			}
+			list = append(list, Match{n, holes})
+		}
+		return true
+	})
+	return list
+}
+
+// match reports whether the node n matches the pattern p,
+// recording the bindings of named wildcards in holes.
+func match(p, n reflect.Value, holes map[string]Node) bool {
+	if name, ok := p.Interface().(*Name); ok && isWildcard(name) {
+		return bind(holes, name.Value, n.Interface())
+	}
+	return p.Type() == n.Type() && equalValues(p, n, holes)
+}
+
+// isWildcard reports whether the pattern identifier name is a wildcard.
+func isWildcard(name *Name) bool {
+	return len(name.Value) > 0 && name.Value[0] == '_'
+}
+
+// bind binds the wildcard name to n if n is an expression and name
+// is not yet bound to a different expression, and reports whether it
+// did so. The blank wildcard _ is not bound.
+func bind(holes map[string]Node, name string, n any) bool {
+	x, ok := n.(Expr)
+	if !ok {
+		return false
+	}
+	if name == "_" {
+		return true
+	}
+	if prev, ok := holes[name]; ok {
+		return Equal(prev, x)
+	}
+	holes[name] = x
+	return true
+}
+
+// Rewrite replaces each subtree of the tree rooted at root which matches
+// pattern (see FindMatches) with a copy of replacement in which the
+// named wildcards bound by the match are replaced by copies of the
+// expressions they matched, and returns the rewritten tree and the
+// number of replacements, in the manner of gofmt -r. Named wildcards
+// in replacement which do not occur in pattern remain unchanged.
+// Subtrees are rewritten bottom-up: the children of a node are rewritten
+// before the node itself is matched against pattern. Since the printer
+// does not take operator precedence into account, substituted operations
+// are parenthesized if they become the operand of an operation or of a
+// selector, index, slice, call, or type assertion expression. A match is not
+// replaced if the result is not acceptable in place of the matched
+// subtree (for instance, if pattern matches an identifier selected
+// in a selector expression but replacement is not an identifier).
+// Nodes shared within the tree are rewritten once.
+func Rewrite(root Node, pattern, replacement Node) (Node, int) {
+	r := rewriter{
+		pattern:     reflect.ValueOf(pattern),
+		replacement: replacement,
+		memo:        make(map[Node]reflect.Value),
+		fresh:       make(map[Node]bool),
+	}
+	return r.node(reflect.ValueOf(root), nodeType).Interface().(Node), r.count
+}
+
+type rewriter struct {
+	pattern     reflect.Value
+	replacement Node
+	memo        map[Node]reflect.Value // rewritten nodes
+	fresh       map[Node]bool          // substituted nodes
+	count       int
+}
+
+// node returns the rewritten node v, which is acceptable
+// as a value of the (field) type t.
+func (r *rewriter) node(v reflect.Value, t reflect.Type) reflect.Value {
+	n := v.Interface().(Node)
+	if x, ok := r.memo[n]; ok && x.Type().AssignableTo(t) {
+		return x
+	}
+	eachNodeField(v, func(f reflect.Value) {
+		f.Set(r.node(nodePointer(f), f.Type()))
+	})
+	parenthesize(n, r.fresh)
+	x := v
+	holes := make(map[string]Node)
+	if match(r.pattern, v, holes) {
+		if y, ok := substitute(r.replacement, holes, r.fresh); ok && y.Type().AssignableTo(t) {
+			x = y
+			r.count++
+		}
+	}
+	r.memo[n] = x
+	return x
+}
+
+// substitute returns a copy of the pattern p in which the bound
+// wildcards are replaced by copies of their bindings, and reports
+// whether all bindings were acceptable in place of the wildcards.
+// The copy and the copies of the bindings are recorded in fresh.
+func substitute(p Node, holes map[string]Node, fresh map[Node]bool) (reflect.Value, bool) {
+	if x := binding(p, holes); x != nil {
+		c := Clone(x)
+		fresh[c] = true
+		return reflect.ValueOf(c), true
+	}
+	ok := true
+	var fill func(v reflect.Value)
+	fill = func(v reflect.Value) {
+		eachNodeField(v, func(f reflect.Value) {
+			if x := binding(f.Interface().(Node), holes); x != nil {
+				c := reflect.ValueOf(Clone(x))
+				if !c.Type().AssignableTo(f.Type()) {
+					ok = false
+					return
+				}
+				f.Set(c)
+				fresh[c.Interface().(Node)] = true
+				return
+			}
+			fill(nodePointer(f))
+		})
+	}
+	c := Clone(p)
+	fill(reflect.ValueOf(c))
+	Inspect(c, func(n Node) bool {
+		parenthesize(n, fresh)
+		return n != nil
+	})
+	fresh[c] = true
+	return reflect.ValueOf(c), ok
+}
+
+// parenthesize wraps the operands of n which are operations
+// recorded in fresh in parentheses.
+func parenthesize(n Node, fresh map[Node]bool) {
+	paren := func(x *Expr) {
+		if op, ok := (*x).(*Operation); ok && fresh[op] {
+			p := new(ParenExpr)
+			p.pos = op.Pos()
+			p.X = op
+			*x = p
+		}
+	}
+	switch n := n.(type) {
+	case *Operation:
+		paren(&n.X)
+		paren(&n.Y)
+	case *SelectorExpr:
+		paren(&n.X)
+	case *IndexExpr:
+		paren(&n.X)
+	case *SliceExpr:
+		paren(&n.X)
+	case *AssertExpr:
+		paren(&n.X)
+	case *CallExpr:
+		paren(&n.Fun)
+	}
+}
+
+// binding returns the binding of n if n is a bound wildcard, or nil.
+func binding(n Node, holes map[string]Node) Node {
+	if name, ok := n.(*Name); ok && isWildcard(name) {
+		return holes[name.Value]
+	}
+	return nil
+}
+
+// eachNodeField calls f for each structural field (or slice or array
+// element) of the node (pointer) v which holds a non-nil node.
+func eachNodeField(v reflect.Value, f func(field reflect.Value)) {
+	x := v.Elem()
+	t := x.Type()
+	for i := range t.NumField() {
+		if !isStructural(t, t.Field(i)) {
+			continue
+		}
+		switch field := x.Field(i); field.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if isNodeValue(field) {
+				f(field)
+			}
+		case reflect.Slice, reflect.Array:
+			for j := range field.Len() {
+				if e := field.Index(j); isNodeValue(e) {
+					f(e)
+				}
+			}
+		}
+	}
+}
+
+// isNodeValue reports whether v holds a non-nil node.
+func isNodeValue(v reflect.Value) bool {
+	return !v.IsNil() && v.Type() != groupType && (v.Kind() == reflect.Interface || v.Type().Implements(nodeType))
+}
+
+// nodePointer returns the pointer to the node held by the field v.
+func nodePointer(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Interface {
+		return v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindMatches(t *testing.T) {
+	const src = `package p
+
+func _() {
+	a = a
+	b.c = b.c
+	a = b
+	f(x, len(x))
+	f(y.z, len(y.z))
+	f(x, len(y))
+	g(1)
+}
+`
+	f := mustParse(t, src, 0)
+	pattern := func(x string) Node {
+		return mustParse(t, "package p; var _ = "+x, 0).DeclList[0].(*VarDecl).Values
+	}
+
+	for _, test := range []struct {
+		pattern string
+		want    string
+	}{
+		{"f(_x, len(_x))", "f(x, len(x)) [_x=x]; f(y.z, len(y.z)) [_x=y.z]"},
+		{"f(_, len(_))", "f(x, len(x)); f(y.z, len(y.z)); f(x, len(y))"},
+		{"len(_)", "len(x); len(y.z); len(y)"},
+		{"_x.z", "y.z [_x=y]; y.z [_x=y]"},
+		{"y._f", "y.z [_f=z]; y.z [_f=z]"},
+		{"g(_)", "g(1)"},
+		{"g(_a, _b)", ""},
+		{"h()", ""},
+	} {
+		var got []string
+		for _, m := range FindMatches(f, pattern(test.pattern)) {
+			s := String(m.Node)
+			if len(m.Bindings) > 0 {
+				var b []string
+				for name, x := range m.Bindings {
+					b = append(b, fmt.Sprintf("%s=%s", name, String(x)))
+				}
+				slices.Sort(b)
+				s += " [" + strings.Join(b, " ") + "]"
+			}
+			got = append(got, s)
+		}
+		if got := strings.Join(got, "; "); got != test.want {
+			t.Errorf("%s: got  %s\nwant %s", test.pattern, got, test.want)
+		}
+	}
+
+	// self-assignments
+	stmt := mustParse(t, "package p; func _() { _x = _x }", 0).DeclList[0].(*FuncDecl).Body.List[0]
+	if got, want := nodeStrings(matchedNodes(FindMatches(f, stmt))), "a = a; b.c = b.c"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func matchedNodes(list []Match) []Node {
+	var nodes []Node
+	for _, m := range list {
+		nodes = append(nodes, m.Node)
+	}
+	return nodes
+}
+
+func TestRewrite(t *testing.T) {
+	expr := func(x string) Node {
+		return mustParse(t, "package p; var _ = "+x, 0).DeclList[0].(*VarDecl).Values
+	}
+	for _, test := range []struct {
+		src, pattern, replacement, want string
+		count                           int
+	}{
+		{"package p; var _ = a[b:len(a)]", "_x[_y:len(_x)]", "_x[_y:]", "package p; var _ = a[b:]", 1},
+		{"package p; var _ = a[b:len(c)]", "_x[_y:len(_x)]", "_x[_y:]", "package p; var _ = a[b:len(c)]", 0},
+		{"package p; var _ = f(g(x), g(y))", "g(_a)", "h(_a, _a)", "package p; var _ = f(h(x, x), h(y, y))", 2},
+		{"package p; var _ = g(g(x))", "g(_a)", "h(_a)", "package p; var _ = h(h(x))", 2},
+		{"package p; var _ = x.y + z", "_a + _b", "_b + _a", "package p; var _ = z + x.y", 1},
+		{"package p; var _ = x.y", "y", "f()", "package p; var _ = x.y", 0}, // y is not an expression here
+		{"package p; var _ = x.y", "y", "z", "package p; var _ = x.z", 1},
+		{"package p; var _ = x + 0", "_ + 0", "_u", "package p; var _ = _u", 1},
+		{"package p; var _ = a + b*c", "_x * _y", "_y - _x", "package p; var _ = a + (c - b)", 1},
+		{"package p; var _ = f(a + b)", "f(_x)", "_x.m()", "package p; var _ = (a + b).m()", 1},
+		{"package p; var _ = f(a + b)", "f(_x)", "g(_x)", "package p; var _ = g(a + b)", 1},
+		{"package p; var _ = id(a + b) * c", "id(_x)", "_x", "package p; var _ = (a + b) * c", 1},
+	} {
+		f := mustParse(t, test.src, 0)
+		n, count := Rewrite(f, expr(test.pattern), expr(test.replacement))
+		if got := lineString(n); got != test.want {
+			t.Errorf("%s: got  %s\nwant %s", test.pattern, got, test.want)
+		}
+		if count != test.count {
+			t.Errorf("%s: got count %d, want %d", test.pattern, count, test.count)
+		}
+	}
+
+	// shared types of grouped fields are rewritten once
+	f := mustParse(t, "package p; func f(a, b T)", 0)
+	n, count := Rewrite(f, expr("T"), expr("[]U"))
+	if got, want := lineString(n), "package p; func f(a, b []U)"; got != want || count != 1 {
+		t.Errorf("got %s (count %d), want %s (count 1)", got, count, want)
+	}
+
+	// the root itself may be rewritten
+	n, count = Rewrite(expr("-(-x)"), expr("-(-_x)"), expr("_x"))
+	if got := lineString(n); got != "x" || count != 1 {
+		t.Errorf("got %s (count %d), want x (count 1)", got, count)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements software metrics computed from syntax trees.
+
+package syntax
+
+import "math"
+
+// Halstead holds the operator and operand counts of a function, as
+// defined by Halstead's software science, from which the established
+// measures of program vocabulary, length, volume, and difficulty (and
+// maintainability indices based on them) are derived.
+type Halstead struct {
+	DistinctOperators int // n1: number of distinct operators
+	DistinctOperands  int // n2: number of distinct operands
+	Operators         int // N1: total number of operators
+	Operands          int // N2: total number of operands
+}
+
+// Vocabulary returns the program vocabulary n = n1 + n2.
+func (h Halstead) Vocabulary() int { return h.DistinctOperators + h.DistinctOperands }
+
+// Length returns the program length N = N1 + N2.
+func (h Halstead) Length() int { return h.Operators + h.Operands }
+
+// Volume returns the program volume V = N * log2(n), or 0 if n is 0.
+func (h Halstead) Volume() float64 {
+	if n := h.Vocabulary(); n > 0 {
+		return float64(h.Length()) * math.Log2(float64(n))
+	}
+	return 0
+}
+
+// Difficulty returns the program difficulty D = n1/2 * N2/n2,
+// or 0 if there are no operands.
+func (h Halstead) Difficulty() float64 {
+	if h.DistinctOperands == 0 {
+		return 0
+	}
+	return float64(h.DistinctOperators) / 2 * float64(h.Operands) / float64(h.DistinctOperands)
+}
+
+// HalsteadMetrics returns the Halstead counts of the body of fn, including
+// the bodies of function literals; the signature of fn is not counted.
+//
+// The operands are the identifiers (including the names of types, fields,
+// packages, and labels) and the basic literals, distinguished by their
+// spelling. The operators are
+//
+//   - the unary and binary operators, where a unary operator is distinct
+//     from the binary operator with the same spelling, as for -x and x - y;
+//   - the assignment operators, such as = and +=, including := and the
+//     increment and decrement statements, and those of range clauses;
+//   - the operations with implicit or bracketed operator syntax: calls
+//     f(x), index expressions x[i], slice expressions x[i:j], selectors
+//     x.f, type assertions x.(T), composite literals T{...}, key-value
+//     pairs k: v, and send statements ch <- v;
+//   - the keywords of statements and declarations, such as if, else, for,
+//     range, return, and var, where the cases of switch and select
+//     statements count as case or default;
+//   - the keywords and punctuation of type literals, such as map, chan,
+//     []T, and ...T; a pointer type *T counts as the unary operator *.
+//
+// Parentheses, braces of blocks, and the separators of lists are not
+// counted. Nodes shared by several parents, such as the type of the
+// parameters a and b in func(a, b int), are counted once.
+func HalsteadMetrics(fn *FuncDecl) Halstead {
+	var h Halstead
+	if fn.Body == nil {
+		return h
+	}
+	operators := make(map[string]bool)
+	operands := make(map[string]bool)
+	operator := func(s string) {
+		h.Operators++
+		operators[s] = true
+	}
+	seen := make(map[Node]bool)
+	litTypes := make(map[*FuncType]bool) // signatures of function literals
+	Inspect(fn.Body, func(n Node) bool {
+		if n == nil || seen[n] {
+			return false
+		}
+		seen[n] = true
+		switch n := n.(type) {
+		// operands
+		case *Name:
+			h.Operands++
+			operands[n.Value] = true
+		case *BasicLit:
+			h.Operands++
+			operands[n.Value] = true
+
+		// expressions
+		case *Operation:
+			if n.Y == nil {
+				operator("unary " + n.Op.String())
+			} else {
+				operator(n.Op.String())
+			}
+		case *CallExpr:
+			operator("()")
+		case *IndexExpr:
+			operator("[]")
+		case *SliceExpr:
+			operator("[:]")
+		case *SelectorExpr:
+			operator(".")
+		case *AssertExpr:
+			operator(".()")
+		case *CompositeLit:
+			operator("{}")
+		case *KeyValueExpr:
+			operator(":")
+		case *FuncLit:
+			operator("func")
+			litTypes[n.Type] = true
+
+		// types
+		case *ArrayType:
+			operator("[N]")
+		case *SliceType:
+			operator("[]T")
+		case *DotsType:
+			operator("...")
+		case *StructType:
+			operator("struct")
+		case *FuncType:
+			if !litTypes[n] { // func keyword counted for the function literal
+				operator("func")
+			}
+		case *InterfaceType:
+			operator("interface")
+		case *MapType:
+			operator("map")
+		case *ChanType:
+			operator("chan")
+
+		// statements
+		case *SendStmt:
+			operator("<-")
+		case *AssignStmt:
+			switch {
+			case n.Op == Def:
+				operator(":=")
+			case n.Rhs == nil:
+				operator(n.Op.String() + n.Op.String()) // ++ or --
+			case n.Op == 0:
+				operator("=")
+			default:
+				operator(n.Op.String() + "=")
+			}
+		case *BranchStmt:
+			operator(n.Tok.String())
+		case *CallStmt:
+			operator(n.Tok.String())
+		case *ReturnStmt:
+			operator("return")
+		case *IfStmt:
+			operator("if")
+			if n.Else != nil {
+				operator("else")
+			}
+		case *ForStmt:
+			operator("for")
+		case *RangeClause:
+			operator("range")
+			if n.Def {
+				operator(":=")
+			} else if n.Lhs != nil {
+				operator("=")
+			}
+		case *SwitchStmt:
+			operator("switch")
+		case *SelectStmt:
+			operator("select")
+		case *CaseClause:
+			if n.Cases != nil {
+				operator("case")
+			} else {
+				operator("default")
+			}
+		case *CommClause:
+			if n.Comm != nil {
+				operator("case")
+			} else {
+				operator("default")
+			}
+		case *ConstDecl:
+			operator("const")
+		case *TypeDecl:
+			operator("type")
+		case *VarDecl:
+			operator("var")
+		}
+		return true
+	})
+	h.DistinctOperators = len(operators)
+	h.DistinctOperands = len(operands)
+	return h
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHalsteadMetrics(t *testing.T) {
+	for _, test := range []struct {
+		body string
+		want Halstead
+	}{
+		{"", Halstead{}},
+		// operators: = + (); operands: x a b f 1
+		{"x = a + b; f(1)", Halstead{3, 5, 3, 5}},
+		// operators: := unary - - ++; operands: x y 1
+		{"x := -y; x = x - 1; x++", Halstead{5, 3, 5, 6}},
+		// operators: if else return == (twice) < ; operands: a b 0
+		{"if a == b { return } else if a < 0 { return }", Halstead{5, 3, 7, 4}},
+		// operators: for range := [] . () var; operands: _ v s fmt Println i int
+		{"for _, v := range s { fmt.Println(v[i]) }; var i int", Halstead{7, 7, 7, 9}},
+		// operators: switch case default = ; operands: x 1 2 y
+		{"switch x { case 1: y = 1; case 2: default: y = 2 }", Halstead{4, 4, 6, 7}},
+		// operators: go func () ...; operands: a b int
+		{"go func(a, b ...int) {}()", Halstead{4, 3, 4, 3}},
+		// operators: := {} : map; operands: m string int "a" 1
+		{`m := map[string]int{"a": 1}`, Halstead{4, 5, 4, 5}},
+	} {
+		f := mustParse(t, "package p; func f(x int) { "+test.body+" }", 0)
+		if got := HalsteadMetrics(funcDecl(t, f, "f")); got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.body, got, test.want)
+		}
+	}
+
+	h := Halstead{DistinctOperators: 4, DistinctOperands: 4, Operators: 10, Operands: 6}
+	if got, want := h.Volume(), 16*math.Log2(8); got != want {
+		t.Errorf("got volume %v, want %v", got, want)
+	}
+	if got, want := h.Difficulty(), 3.0; got != want {
+		t.Errorf("got difficulty %v, want %v", got, want)
+	}
+}
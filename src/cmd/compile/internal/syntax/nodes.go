@@ -36,11 +36,21 @@ func (*node) aNode()           {}
 
 // package PkgName; DeclList[0], DeclList[1], ...
 type File struct {
-	Pragma    Pragma
-	PkgName   *Name
-	DeclList  []Decl
-	EOF       Pos
-	GoVersion string
+	Pragma     Pragma
+	Directives []*Directive // //go: directives before the package clause, in source order
+	PkgName    *Name
+	DeclList   []Decl
+	Comments   []*Comment // in source order; only set in KeepComments mode
+	EOF        Pos
+	GoVersion  string
+	node
+}
+
+// A Directive represents a //go: directive, such as a //go:build
+// constraint, before the package clause of a file. Text includes
+// the comment marker but not a trailing newline.
+type Directive struct {
+	Text string
 	node
 }
 
@@ -475,14 +485,21 @@ func (simpleStmt) aSimpleStmt() {}
 type CommentKind uint
 
 const (
-	Above CommentKind = iota
-	Below
-	Left
-	Right
+	Above CommentKind = iota // on lines of its own, before the next token
+	Below                    // on lines of its own, before a closing ), ], }, or EOF
+	Left                     // before a token on the same line
+	Right                    // after a token on the same line
 )
 
+// A Comment represents a single //-style or /*-style comment.
+// Text includes the comment markers but not a trailing newline.
+// Kind describes the placement of the comment relative to the
+// surrounding tokens. Comments are not part of the regular syntax
+// tree; they are collected in File.Comments if the KeepComments
+// mode is set and can be visited with WalkWithComments.
 type Comment struct {
 	Kind CommentKind
 	Text string
 	Next *Comment
+	node
 }
@@ -0,0 +1,215 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the computation of byte offsets
+// of syntax nodes in their source.
+
+package syntax
+
+import "bytes"
+
+// OffsetSpan returns the byte offsets of the start and the end (the
+// offset immediately following the last byte) of the source text of n
+// in src, which must be the source from which n was parsed. Unlike
+// EndPos, which is approximate, the span includes all tokens of n, such
+// as closing parentheses and brackets. The start offset corresponds to
+// StartPos(n), except that the span of a declaration which is not part
+// of a group includes its keyword, such as func or var; the span of a
+// declaration within a group, as in var ( ... ), starts after the shared
+// keyword, and the span of a *FuncType in a function type or declaration
+// starts at the opening parenthesis of its parameter list.
+//
+// Since positions do not record byte offsets, OffsetSpan scans src to
+// map positions to offsets. The result is undefined if n was not parsed
+// from src.
+func OffsetSpan(src []byte, n Node) (start, end int) {
+	if _, ok := n.(*File); ok {
+		return 0, len(src)
+	}
+
+	start = offsetOf(src, StartPos(n))
+	if kw := declKeyword(n); kw != 0 {
+		start = keywordOffset(src, start, kw)
+	}
+	last := lastTokenPos(n)
+
+	// Scan the tokens from start, tracking the nesting depth of
+	// parentheses, brackets, and braces, up to and including the
+	// last token known to belong to n (the one at last), and then
+	// until all opened parentheses, brackets, and braces are closed.
+	// A struct or interface keyword is followed by a brace-enclosed
+	// list that belongs to n as well.
+	var s scanner
+	s.init(bytes.NewReader(src[start:]), func(line, col uint, msg string) {}, 0)
+	line, col := lineCol(src, start, last)
+	depth := 0
+	seen := false // whether the token at last was scanned
+	for {
+		s.next()
+		if s.tok == _EOF {
+			return start, len(src)
+		}
+		switch s.tok {
+		case _Lparen, _Lbrack, _Lbrace:
+			depth++
+		case _Rparen, _Rbrack, _Rbrace:
+			depth--
+		}
+		if !seen && (s.line > line || s.line == line && s.col >= col) {
+			seen = true
+			if s.tok == _Struct || s.tok == _Interface {
+				depth++ // the opening brace follows
+				s.next()
+			}
+		}
+		if seen && depth <= 0 {
+			l, c := s.pos() // position immediately following the token
+			return start, start + relOffset(src[start:], l, c)
+		}
+	}
+}
+
+// declKeyword returns the keyword of the declaration n
+// if n is not part of a group, or 0.
+func declKeyword(n Node) token {
+	switch n := n.(type) {
+	case *ImportDecl:
+		if n.Group == nil {
+			return _Import
+		}
+	case *ConstDecl:
+		if n.Group == nil {
+			return _Const
+		}
+	case *TypeDecl:
+		if n.Group == nil {
+			return _Type
+		}
+	case *VarDecl:
+		if n.Group == nil {
+			return _Var
+		}
+	case *FuncDecl:
+		return _Func
+	}
+	return 0
+}
+
+// keywordOffset returns the offset of the keyword kw if it is the token
+// preceding the one at offset start in src, and start otherwise.
+func keywordOffset(src []byte, start int, kw token) int {
+	i := bytes.LastIndex(src[:start], []byte(tokstring(kw)))
+	if i < 0 {
+		return start
+	}
+	var s scanner
+	s.init(bytes.NewReader(src[i:]), func(line, col uint, msg string) {}, 0)
+	s.next()
+	if s.tok != kw {
+		return start
+	}
+	s.next()
+	if i+relOffset(src[i:], s.line, s.col) != start {
+		return start
+	}
+	return i
+}
+
+// NodeText returns the source text of n in src, which must be the source
+// from which n was parsed, including any comments within it; the text
+// is delimited by OffsetSpan(src, n). The result is false if the
+// positions of n are unknown or do not denote a position in src.
+func NodeText(src []byte, n Node) (string, bool) {
+	if !isValidPos(src, StartPos(n)) || !isValidPos(src, lastTokenPos(n)) {
+		return "", false
+	}
+	start, end := OffsetSpan(src, n)
+	return string(src[start:end]), true
+}
+
+// isValidPos reports whether pos denotes a position in src.
+func isValidPos(src []byte, pos Pos) bool {
+	if !pos.IsKnown() || pos.Col() == 0 {
+		return false
+	}
+	offs := 0
+	for line := uint(1); line < pos.Line(); line++ {
+		i := bytes.IndexByte(src[offs:], '\n')
+		if i < 0 {
+			return false
+		}
+		offs += i + 1
+	}
+	eol := bytes.IndexByte(src[offs:], '\n')
+	if eol < 0 {
+		eol = len(src) - offs
+	}
+	return int(pos.Col()-1) <= eol
+}
+
+// lastTokenPos returns the maximum position of the nodes in the tree
+// rooted at n, and of the closing braces and case clause colons of the
+// respective nodes: the position of a token which belongs to n and which
+// is at or before the last token of n.
+func lastTokenPos(n Node) Pos {
+	last := n.Pos()
+	update := func(pos Pos) {
+		if pos.IsKnown() && pos.Cmp(last) > 0 {
+			last = pos
+		}
+	}
+	Inspect(n, func(n Node) bool {
+		if n == nil {
+			return false
+		}
+		update(n.Pos())
+		switch n := n.(type) {
+		case *CompositeLit:
+			update(n.Rbrace)
+		case *BlockStmt:
+			update(n.Rbrace)
+		case *SwitchStmt:
+			update(n.Rbrace)
+		case *SelectStmt:
+			update(n.Rbrace)
+		case *CaseClause:
+			update(n.Colon)
+		case *CommClause:
+			update(n.Colon)
+		}
+		return true
+	})
+	return last
+}
+
+// offsetOf returns the byte offset in src of pos.
+func offsetOf(src []byte, pos Pos) int {
+	offs := 0
+	for line := uint(1); line < pos.Line(); line++ {
+		i := bytes.IndexByte(src[offs:], '\n')
+		if i < 0 {
+			return len(src)
+		}
+		offs += i + 1
+	}
+	return min(offs+int(pos.Col()-1), len(src))
+}
+
+// lineCol returns the line and column of pos relative to the
+// beginning of src[start:], in the manner of scanner positions.
+func lineCol(src []byte, start int, pos Pos) (line, col uint) {
+	seg := src[start:offsetOf(src, pos)]
+	i := bytes.LastIndexByte(seg, '\n')
+	return linebase + uint(bytes.Count(seg, []byte("\n"))), colbase + uint(len(seg)-(i+1))
+}
+
+// relOffset returns the byte offset in src
+// of the given scanner line and column.
+func relOffset(src []byte, line, col uint) int {
+	offs := 0
+	for ; line > linebase; line-- {
+		offs += bytes.IndexByte(src[offs:], '\n') + 1
+	}
+	return offs + int(col-colbase)
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOffsetSpan(t *testing.T) {
+	const src = `package p
+
+import "fmt"
+
+type T struct {
+	x, y int ` + "`tag`" + `
+	_    struct{}
+}
+
+func (t *T) m(a []int) (int, error) {
+	x := f(a[1:], (t.x)) // comment
+	x++
+	v, ok := t.(interface{})
+	switch y := t.(type) {
+	case int:
+	default:
+		return 0, nil
+	}
+	fmt.Println(x, []int{1, 2}, func() {})
+	return a[0], nil
+}
+
+func decl(a, b int)
+`
+	f := mustParse(t, src, 0)
+
+	// spans of selected nodes, identified by their
+	// kind and the start of their source text
+	want := []string{
+		`File:` + src,
+		`ImportDecl:import "fmt"`,
+		"TypeDecl:type T struct {\n\tx, y int `tag`\n\t_    struct{}\n}",
+		"StructType:struct {\n\tx, y int `tag`\n\t_    struct{}\n}",
+		`StructType:struct{}`,
+		`FuncType:(a []int) (int, error)`,
+		`CallExpr:f(a[1:], (t.x))`,
+		`SliceExpr:a[1:]`,
+		`ParenExpr:(t.x)`,
+		`AssignStmt:x++`,
+		`AssertExpr:t.(interface{})`,
+		`InterfaceType:interface{}`,
+		"CaseClause:case int:",
+		"CaseClause:default:\n\t\treturn 0, nil",
+		`CompositeLit:[]int{1, 2}`,
+		`FuncLit:func() {}`,
+		`IndexExpr:a[0]`,
+		`ReturnStmt:return a[0], nil`,
+		`FuncType:(a, b int)`,
+		`FuncDecl:func decl(a, b int)`,
+	}
+	var got []string
+	Inspect(f, func(n Node) bool {
+		if n == nil {
+			return false
+		}
+		start, end := OffsetSpan([]byte(src), n)
+		got = append(got, nodeKind(n)+":"+src[start:end])
+		switch n := n.(type) {
+		case *Name:
+			if src[start:end] != n.Value {
+				t.Errorf("got span %q for name %s", src[start:end], n.Value)
+			}
+		case *BasicLit:
+			if src[start:end] != n.Value {
+				t.Errorf("got span %q for literal %s", src[start:end], n.Value)
+			}
+		}
+		return true
+	})
+	for _, w := range want {
+		if !slices.Contains(got, w) {
+			t.Errorf("span %q not found", w)
+		}
+	}
+}
+
+func TestNodeText(t *testing.T) {
+	const src = `package p
+
+func f() {
+	g(a, /* b */ c)
+}
+`
+	f := mustParse(t, src, 0)
+	call := funcDecl(t, f, "f").Body.List[0].(*ExprStmt).X
+	if text, ok := NodeText([]byte(src), call); !ok || text != "g(a, /* b */ c)" {
+		t.Errorf("got %q, %v", text, ok)
+	}
+	if text, ok := NodeText([]byte(src), f.DeclList[0]); !ok || text != "func f() {\n\tg(a, /* b */ c)\n}" {
+		t.Errorf("got %q, %v", text, ok)
+	}
+	if _, ok := NodeText([]byte("package p"), call); ok {
+		t.Errorf("got text for position beyond source")
+	}
+	if _, ok := NodeText([]byte(src), NewName(Pos{}, "x")); ok {
+		t.Errorf("got text for unknown position")
+	}
+}
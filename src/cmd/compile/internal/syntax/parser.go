@@ -22,11 +22,15 @@ type parser struct {
 	pragh PragmaHandler
 	scanner
 
-	base      *PosBase // current position base
-	first     error    // first error encountered
-	errcnt    int      // number of errors encountered
-	pragma    Pragma   // pragmas
-	goVersion string   // Go version from //go:build line
+	base      *PosBase   // current position base
+	first     error      // first error encountered
+	errcnt    int        // number of errors encountered
+	pragma    Pragma     // pragmas
+	goVersion string     // Go version from //go:build line
+	comments  []*Comment // collected comments (KeepComments mode only)
+
+	directives []*Directive     // //go: directives before the package clause
+	pending    []pendingComment // comments whose Kind depends on the next token
 
 	top    bool   // in top of file (before package clause)
 	fnest  int    // function nesting level (for error handling)
@@ -40,6 +44,14 @@ func (p *parser) init(file *PosBase, r io.Reader, errh ErrorHandler, pragh Pragm
 	p.errh = errh
 	p.mode = mode
 	p.pragh = pragh
+	p.comments = nil
+	p.pending = nil
+	p.directives = nil
+
+	smode := directives
+	if mode&KeepComments != 0 {
+		smode = comments
+	}
 	p.scanner.init(
 		r,
 		// Error and directive handler for scanner.
@@ -53,7 +65,19 @@ func (p *parser) init(file *PosBase, r io.Reader, errh ErrorHandler, pragh Pragm
 				return
 			}
 
-			// otherwise it must be a comment containing a line or go: directive.
+			// otherwise it must be a comment, possibly containing a line or go: directive.
+			if mode&KeepComments != 0 {
+				c := &Comment{Text: msg}
+				c.pos = p.posAt(line, col)
+				p.comments = append(p.comments, c)
+				if p.scanner.blank {
+					end := line + uint(strings.Count(msg, "\n"))
+					p.pending = append(p.pending, pendingComment{c, end})
+				} else {
+					c.Kind = Right // a token precedes c on the same line
+				}
+			}
+
 			// //line directives must be at the start of the line (column colbase).
 			// /*line*/ directives can be anywhere in the line.
 			text := commentText(msg)
@@ -75,6 +99,11 @@ func (p *parser) init(file *PosBase, r io.Reader, errh ErrorHandler, pragh Pragm
 
 			// go: directive (but be conservative and test)
 			if strings.HasPrefix(text, "go:") {
+				if p.top && msg[1] == '/' {
+					d := &Directive{Text: msg}
+					d.pos = p.posAt(line, col)
+					p.directives = append(p.directives, d)
+				}
 				if p.top && strings.HasPrefix(msg, "//go:build") {
 					if x, err := constraint.Parse(msg); err == nil {
 						p.goVersion = constraint.GoVersion(x)
@@ -85,7 +114,7 @@ func (p *parser) init(file *PosBase, r io.Reader, errh ErrorHandler, pragh Pragm
 				}
 			}
 		},
-		directives,
+		smode,
 	)
 
 	p.base = file
@@ -98,6 +127,30 @@ func (p *parser) init(file *PosBase, r io.Reader, errh ErrorHandler, pragh Pragm
 	p.indent = nil
 }
 
+// A pendingComment is a comment not preceded by a token on the
+// same line, and the (unadjusted) line on which the comment ends.
+type pendingComment struct {
+	*Comment
+	line uint
+}
+
+// next advances to the next token and determines the Kind of the
+// comments preceding it which are not preceded by a token themselves.
+func (p *parser) next() {
+	p.scanner.next()
+	for _, c := range p.pending {
+		switch {
+		case p.line == c.line:
+			c.Kind = Left
+		case p.tok == _Rparen || p.tok == _Rbrack || p.tok == _Rbrace || p.tok == _EOF || p.tok == _Semi && p.lit == "EOF":
+			c.Kind = Below
+		default:
+			c.Kind = Above
+		}
+	}
+	p.pending = p.pending[:0]
+}
+
 // takePragma returns the current parsed pragmas
 // and clears them from the parser state.
 func (p *parser) takePragma() Pragma {
@@ -401,6 +454,7 @@ func (p *parser) fileOrNil() *File {
 
 	// PackageClause
 	f.GoVersion = p.goVersion
+	f.Directives = p.directives
 	p.top = false
 	if !p.got(_Package) {
 		p.syntaxError("package statement must be first")
@@ -471,6 +525,7 @@ func (p *parser) fileOrNil() *File {
 
 	p.clearPragma()
 	f.EOF = p.pos()
+	f.Comments = p.comments
 
 	p.apply(f)
 	return f
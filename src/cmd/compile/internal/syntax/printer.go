@@ -21,13 +21,39 @@ const (
 	ShortForm             // like LineForm but print "…" for non-empty function or composite literal bodies
 )
 
+// Options control print formatting beyond the choice of Form.
+// The zero value prints like gofmt: in the default form, with
+// tab indentation, and without redundant semicolons.
+type Options struct {
+	Form           Form // print form; see Form
+	UseSpaces      bool // indent with spaces instead of tabs
+	TabWidth       int  // number of spaces per indentation level if UseSpaces is set; 0 means 8
+	Semicolons     bool // terminate all statements and declarations with a semicolon, even at the end of a line
+	CollapseBlocks bool // print blocks consisting of a single simple statement on one line, as in { return x }
+}
+
 // Fprint prints node x to w in the specified form.
 // It returns the number of bytes written, and whether there was an error.
+// It is shorthand for (&Options{Form: form}).Fprint(w, x).
 func Fprint(w io.Writer, x Node, form Form) (n int, err error) {
+	return (&Options{Form: form}).Fprint(w, x)
+}
+
+// Fprint prints node x to w as specified by the options o.
+// It returns the number of bytes written, and whether there was an error.
+func (o *Options) Fprint(w io.Writer, x Node) (n int, err error) {
 	p := printer{
 		output:     w,
-		form:       form,
-		linebreaks: form == 0,
+		form:       o.Form,
+		linebreaks: o.Form == 0,
+		semis:      o.Semicolons,
+		collapse:   o.CollapseBlocks,
+	}
+	if o.UseSpaces {
+		p.spaces = o.TabWidth
+		if p.spaces <= 0 {
+			p.spaces = 8
+		}
 	}
 
 	defer func() {
@@ -78,6 +104,9 @@ type printer struct {
 	written    int // number of bytes written
 	form       Form
 	linebreaks bool // print linebreaks instead of semis
+	spaces     int  // if > 0, number of blanks per indentation level (instead of a tab)
+	semis      bool // print all semis, including those implied by linebreaks or closing parens
+	collapse   bool // print blocks consisting of a single simple statement on one line
 
 	indent  int // current indentation level
 	nlcount int // number of consecutive newlines
@@ -98,6 +127,7 @@ func (p *printer) write(data []byte) {
 
 var (
 	tabBytes    = []byte("\t\t\t\t\t\t\t\t")
+	blankBytes  = []byte("                ")
 	newlineByte = []byte("\n")
 	blankByte   = []byte(" ")
 )
@@ -108,12 +138,15 @@ func (p *printer) writeBytes(data []byte) {
 	}
 	if p.nlcount > 0 && p.indent > 0 {
 		// write indentation
-		n := p.indent
-		for n > len(tabBytes) {
-			p.write(tabBytes)
-			n -= len(tabBytes)
+		fill, n := tabBytes, p.indent
+		if p.spaces > 0 {
+			fill, n = blankBytes, p.indent*p.spaces
+		}
+		for n > len(fill) {
+			p.write(fill)
+			n -= len(fill)
 		}
-		p.write(tabBytes[:n])
+		p.write(fill[:n])
 	}
 	p.write(data)
 	p.nlcount = 0
@@ -160,6 +193,9 @@ func (p *printer) flush(next token) {
 	for i := len(p.pending) - 1; i >= 0; i-- {
 		switch p.pending[i].kind {
 		case semi:
+			if p.semis {
+				break // keep all semis
+			}
 			k := semi
 			if sawParen {
 				sawParen = false
@@ -571,7 +607,11 @@ func (p *printer) printRawNode(n Node) {
 
 	case *BlockStmt:
 		p.print(_Lbrace)
-		if len(n.List) > 0 {
+		if p.collapse && len(n.List) == 1 && collapsible(n.List[0]) {
+			p.print(blank)
+			p.printStmtList(n.List, true)
+			p.print(blank)
+		} else if len(n.List) > 0 {
 			p.print(newline, indent)
 			p.printStmtList(n.List, true)
 			p.print(outdent, newline)
@@ -717,7 +757,18 @@ func (p *printer) printRawNode(n Node) {
 		p.print(_Rparen)
 
 	// files
+	case *Directive:
+		p.print(_Name, n.Text)
+
 	case *File:
+		if p.linebreaks && len(n.Directives) > 0 {
+			// directives are line comments; they cannot be
+			// printed in LineForm or ShortForm
+			for _, d := range n.Directives {
+				p.print(d, newline)
+			}
+			p.print(newline)
+		}
 		p.print(_Package, blank, n.PkgName)
 		if len(n.DeclList) > 0 {
 			p.print(_Semi, newline, newline)
@@ -959,7 +1010,7 @@ func (p *printer) printStmtList(list []Stmt, braces bool) {
 		p.print(x, _Semi)
 		if i+1 < len(list) {
 			p.print(newline)
-		} else if braces {
+		} else if braces && !p.semis {
 			// Print an extra semicolon if the last statement is
 			// an empty statement and we are in a braced block
 			// because one semicolon is automatically removed.
@@ -970,6 +1021,30 @@ func (p *printer) printStmtList(list []Stmt, braces bool) {
 	}
 }
 
+// collapsible reports whether the statement s may be printed on the
+// same line as the braces of the block containing it: s must be a simple
+// statement (or a return, branch, go, or defer statement) which does not
+// contain function literals or composite literals whose elements may be
+// printed on separate lines.
+func collapsible(s Stmt) bool {
+	switch s.(type) {
+	case *ExprStmt, *SendStmt, *AssignStmt, *BranchStmt, *CallStmt, *ReturnStmt:
+	default:
+		return false
+	}
+	ok := true
+	Inspect(s, func(n Node) bool {
+		switch n := n.(type) {
+		case *FuncLit:
+			ok = false
+		case *CompositeLit:
+			ok = ok && n.NKeys == 0
+		}
+		return ok
+	})
+	return ok
+}
+
 func (p *printer) printSwitchBody(list []*CaseClause) {
 	p.print(_Lbrace)
 	if len(list) > 0 {
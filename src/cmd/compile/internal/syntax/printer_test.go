@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -52,6 +53,118 @@ func TestPrintError(t *testing.T) {
 	}
 }
 
+func TestPrintOptions(t *testing.T) {
+	const src = `package p
+
+func f(x int) int {
+	if x > 0 {
+		return x
+	}
+	for {
+		x++
+		g(func() {
+			x--
+		})
+	}
+	{
+		x = T{
+			a: 1,
+		}
+	}
+	{
+		x = T{1}
+	}
+	return 0
+}`
+	for _, test := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, src},
+		{Options{UseSpaces: true, TabWidth: 2}, strings.ReplaceAll(src, "\t", "  ")},
+		{Options{UseSpaces: true}, strings.ReplaceAll(src, "\t", "        ")},
+		{Options{Semicolons: true}, `package p;
+
+func f(x int) int {
+	if x > 0 {
+		return x;
+	};
+	for {
+		x++;
+		g(func() {
+			x--;
+		});
+	};
+	{
+		x = T{
+			a: 1,
+		};
+	};
+	{
+		x = T{1};
+	};
+	return 0;
+}`},
+		{Options{CollapseBlocks: true}, `package p
+
+func f(x int) int {
+	if x > 0 { return x }
+	for {
+		x++
+		g(func() { x-- })
+	}
+	{
+		x = T{
+			a: 1,
+		}
+	}
+	{ x = T{1} }
+	return 0
+}`},
+		{Options{Form: LineForm, Semicolons: true}, "package p; func f(x int) int { if x > 0 { return x; }; for { x++; g(func() { x--; }); }; { x = T{ a: 1, }; }; { x = T{1}; }; return 0; }"},
+	} {
+		var buf strings.Builder
+		if _, err := test.opts.Fprint(&buf, mustParse(t, src, 0)); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%+v:\ngot:\n%s\nwant:\n%s", test.opts, got, test.want)
+		}
+	}
+}
+
+// TestPrintOptionsSources verifies that the package's own sources printed
+// with non-default options parse again, and print as before in the default
+// form.
+func TestPrintOptionsSources(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{UseSpaces: true, TabWidth: 4, Semicolons: true, CollapseBlocks: true}
+	for _, filename := range files {
+		f, err := ParseFile(filename, nil, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf strings.Builder
+		if _, err := opts.Fprint(&buf, f); err != nil {
+			t.Fatal(err)
+		}
+		g, err := Parse(nil, strings.NewReader(buf.String()), nil, nil, 0)
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+			continue
+		}
+		var want, got strings.Builder
+		Fprint(&want, f, 0)
+		Fprint(&got, g, 0)
+		if got.String() != want.String() {
+			t.Errorf("%s: printing with %+v changed the syntax tree", filename, opts)
+		}
+	}
+}
+
 var stringTests = [][2]string{
 	dup("package p"),
 	dup("package p; type _ int; type T1 = struct{}; type ( _ *struct{}; T2 = float32 )"),
@@ -0,0 +1,374 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements queries about the relationships
+// between nodes of a syntax tree.
+
+package syntax
+
+// IsMethodCall reports whether sel, which must be a node of the tree
+// rooted at root, is the (possibly parenthesized) function of a call
+// expression, as in x.m(), rather than a method value, as in f := x.m.
+// Without type information, a qualified function call such as pkg.f()
+// is also reported as a method call.
+func IsMethodCall(sel *SelectorExpr, root Node) bool {
+	found := false
+	Inspect(root, func(n Node) bool {
+		if found {
+			return false
+		}
+		if call, ok := n.(*CallExpr); ok && Unparen(call.Fun) == sel {
+			found = true
+		}
+		return true
+	})
+	return found
+}
+
+// IsCommaOk reports whether the type assertion x, which must be a node
+// of the tree rooted at root, is used in a comma-ok assignment or variable
+// declaration, as in v, ok := x.(T) or var v, ok = x.(T), which does not
+// panic if the assertion fails.
+func IsCommaOk(x *AssertExpr, root Node) bool {
+	found := false
+	Inspect(root, func(n Node) bool {
+		if found {
+			return false
+		}
+		switch n := n.(type) {
+		case *AssignStmt:
+			found = len(UnpackListExpr(n.Lhs)) == 2 && Unparen(n.Rhs) == x
+		case *VarDecl:
+			found = len(n.NameList) == 2 && Unparen(n.Values) == x
+		}
+		return true
+	})
+	return found
+}
+
+// WalkSelectors calls f for each selector expression in the tree
+// rooted at root, in pre-order. The isCall argument reports whether
+// the selector expression is the (possibly parenthesized) function
+// of a call expression (see IsMethodCall).
+func WalkSelectors(root Node, f func(sel *SelectorExpr, isCall bool)) {
+	calls := make(map[*SelectorExpr]bool)
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *CallExpr:
+			if sel, ok := Unparen(n.Fun).(*SelectorExpr); ok {
+				calls[sel] = true
+			}
+		case *SelectorExpr:
+			f(n, calls[n])
+		}
+		return true
+	})
+}
+
+// FindPair searches the tree rooted at root in pre-order for the
+// first pair of a node and one of its direct children for which
+// pred(parent, child) returns true, and returns that pair. The search
+// stops as soon as a pair is found. The result is (nil, nil, false)
+// if there is no such pair.
+func FindPair(root Node, pred func(parent, child Node) bool) (parent, child Node, found bool) {
+	var find func(p Node) bool
+	find = func(p Node) bool {
+		return !eachChild(p, func(c Node) bool {
+			if pred(p, c) {
+				parent, child, found = p, c, true
+				return false
+			}
+			return !find(c)
+		})
+	}
+	find(root)
+	return
+}
+
+// EnclosingCase returns the innermost case clause (*CaseClause) or
+// select case (*CommClause) of the tree rooted at root which contains
+// the statement target, or nil if there is none or target is not in
+// the tree. The clause may belong to a switch or select statement
+// outside a function literal containing target.
+func EnclosingCase(root Node, target Stmt) Node {
+	var clause Node
+	var find func(n, c Node) bool
+	find = func(n, c Node) bool {
+		if n == target {
+			clause = c
+			return true
+		}
+		switch n.(type) {
+		case *CaseClause, *CommClause:
+			c = n
+		}
+		return !eachChild(n, func(x Node) bool {
+			return !find(x, c)
+		})
+	}
+	find(root, nil)
+	return clause
+}
+
+// EnclosingBreakable returns the innermost for, switch, or select
+// statement of the tree rooted at root to which an unlabeled break
+// statement at the position of target would refer: the statement whose
+// body (or the body of one of whose clauses) contains target, within
+// the same function. The result is nil if there is no such statement
+// or target is not in the tree. Note that an unlabeled continue
+// statement always refers to the innermost for statement, which may
+// enclose the result.
+func EnclosingBreakable(root, target Node) Node {
+	parents := ParentMap(root)
+	if _, ok := parents[target]; !ok {
+		return nil
+	}
+	var child, grandchild Node // child and grandchild of p on the path to target
+	for p, n := parents[target], target; p != nil; p, n = parents[p], p {
+		child, grandchild = n, child
+		switch p := p.(type) {
+		case *FuncDecl, *FuncLit:
+			return nil
+		case *ForStmt:
+			if child == p.Body {
+				return p
+			}
+		case *SwitchStmt:
+			if cc, ok := child.(*CaseClause); ok && containsStmt(cc.Body, grandchild) {
+				return p
+			}
+		case *SelectStmt:
+			if cc, ok := child.(*CommClause); ok && containsStmt(cc.Body, grandchild) {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// containsStmt reports whether list contains the node n.
+func containsStmt(list []Stmt, n Node) bool {
+	for _, s := range list {
+		if s == n {
+			return true
+		}
+	}
+	return false
+}
+
+// AnnotateParents returns the parent map of the tree rooted at root.
+// AnnotateParents is equivalent to ParentMap, which it predates.
+//
+// Deprecated: Use ParentMap.
+func AnnotateParents(root Node) map[Node]Node {
+	return ParentMap(root)
+}
+
+// ParentMap returns a map from each node of the tree rooted at root to
+// its parent, as determined by Walk; root is mapped to nil. Nodes shared
+// by multiple parents (such as the type of fields declared together, as
+// in a, b int) are mapped to the parent through which they are first
+// reached in pre-order. The parent of a node n is parents[n]; see also
+// Ancestors and EnclosingFunc.
+func ParentMap(root Node) map[Node]Node {
+	parents := map[Node]Node{root: nil}
+	var visit func(p Node) bool
+	visit = func(p Node) bool {
+		return eachChild(p, func(c Node) bool {
+			if _, ok := parents[c]; !ok {
+				parents[c] = p
+			}
+			return visit(c)
+		})
+	}
+	visit(root)
+	return parents
+}
+
+// ReWalkSubtree supports the incremental analysis of a tree after a
+// localized edit: the subtree old of a tree has been replaced by new,
+// which may be old itself if old was modified in place, and parents is
+// the parent map of the tree (see ParentMap) before the edit. ReWalkSubtree
+// updates parents to reflect the edit, removing the nodes of old and
+// adding those of new, which takes the place of old as the child of its
+// parent; and then calls Walk(new, v). The cost is proportional to the
+// size of old and new, rather than to the size of the tree, except for
+// in-place modifications, for which all entries of parents are checked
+// since the nodes removed from old are no longer reachable. The subtree
+// old must not share nodes with the rest of the tree, which holds for all
+// nodes except for the fields of a group of fields declared together, as
+// in a, b int, and their shared type.
+func ReWalkSubtree(old, new Node, parents map[Node]Node, v Visitor) {
+	parent := parents[old]
+
+	if new == old {
+		// remove the descendants of old, including those
+		// which are no longer reachable from old
+		var below []Node
+		for n := range parents {
+			for p := parents[n]; p != nil; p = parents[p] {
+				if p == old {
+					below = append(below, n)
+					break
+				}
+			}
+		}
+		for _, n := range below {
+			delete(parents, n)
+		}
+	}
+
+	// remove old, and its descendants reached through it
+	delete(parents, old)
+	var remove func(p Node) bool
+	remove = func(p Node) bool {
+		return eachChild(p, func(c Node) bool {
+			if q, ok := parents[c]; ok && q == p {
+				delete(parents, c)
+				remove(c)
+			}
+			return true
+		})
+	}
+	remove(old)
+
+	// add new and its descendants
+	parents[new] = parent
+	var add func(p Node) bool
+	add = func(p Node) bool {
+		return eachChild(p, func(c Node) bool {
+			if _, ok := parents[c]; !ok {
+				parents[c] = p
+			}
+			return add(c)
+		})
+	}
+	add(new)
+
+	Walk(new, v)
+}
+
+// Ancestors returns the ancestors of n according to the parent map
+// parents (see ParentMap), starting with the parent of n and ending
+// with the root.
+func Ancestors(parents map[Node]Node, n Node) []Node {
+	var list []Node
+	for p := parents[n]; p != nil; p = parents[p] {
+		list = append(list, p)
+	}
+	return list
+}
+
+// IsAncestor reports whether ancestor is a (proper) ancestor of
+// descendant in the tree rooted at root, that is, whether ancestor
+// lies on the path from root to descendant and is not descendant
+// itself. Shared nodes are treated as in ParentMap.
+func IsAncestor(root, ancestor, descendant Node) bool {
+	parents := ParentMap(root)
+	if _, ok := parents[descendant]; !ok {
+		return false
+	}
+	for p := parents[descendant]; p != nil; p = parents[p] {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// CommonAncestor returns the lowest common ancestor of the nodes a and b
+// in the tree rooted at root: the deepest node which is a or an ancestor
+// of a and also b or an ancestor of b. In particular, if a is an ancestor
+// of b, the result is a. The result is nil if a or b is not part of the
+// tree. Shared nodes are treated as in ParentMap.
+func CommonAncestor(root, a, b Node) Node {
+	parents := ParentMap(root)
+	if _, ok := parents[a]; !ok {
+		return nil
+	}
+	if _, ok := parents[b]; !ok {
+		return nil
+	}
+	onPath := make(map[Node]bool) // a and its ancestors
+	for n := a; n != nil; n = parents[n] {
+		onPath[n] = true
+	}
+	for n := b; n != nil; n = parents[n] {
+		if onPath[n] {
+			return n
+		}
+	}
+	return nil // unreachable: root is on both paths
+}
+
+// EnclosingFunc returns the innermost function declaration (*FuncDecl)
+// or function literal (*FuncLit) which is an ancestor of n according
+// to the parent map parents (see ParentMap), or nil.
+func EnclosingFunc(parents map[Node]Node, n Node) Node {
+	for p := parents[n]; p != nil; p = parents[p] {
+		switch p.(type) {
+		case *FuncDecl, *FuncLit:
+			return p
+		}
+	}
+	return nil
+}
+
+// FuncBounds returns the first and last statement of the body of fn,
+// which are the same statement if the body consists of a single
+// statement, for instance to insert statements at the entry and exit
+// of fn. The result is (nil, nil, false) if fn has no body (because it
+// is implemented externally) or an empty body. Note that the last
+// statement is not necessarily executed last, or at all.
+func FuncBounds(fn *FuncDecl) (first, last Stmt, ok bool) {
+	if fn.Body == nil || len(fn.Body.List) == 0 {
+		return nil, nil, false
+	}
+	list := fn.Body.List
+	return list[0], list[len(list)-1], true
+}
+
+// A LabelKind classifies the statement labeled by a labeled statement.
+type LabelKind uint8
+
+const (
+	LabeledOther  LabelKind = iota // any other statement, such as a block
+	LabeledLoop                    // for statement
+	LabeledSwitch                  // switch or type switch statement
+	LabeledSelect                  // select statement
+)
+
+// A LabeledInfo describes a labeled statement.
+type LabeledInfo struct {
+	Label *Name     // the label
+	Stmt  Stmt      // the labeled statement
+	Kind  LabelKind // the kind of Stmt
+}
+
+// LabeledStatements returns the labeled statements in the body of fn,
+// including those in function literals, in source order. Labels may
+// only be used by break and continue statements if they label a loop,
+// or (for break statements) a switch or select statement.
+func LabeledStatements(fn *FuncDecl) []LabeledInfo {
+	if fn.Body == nil {
+		return nil
+	}
+	var list []LabeledInfo
+	Inspect(fn.Body, func(n Node) bool {
+		if s, ok := n.(*LabeledStmt); ok {
+			kind := LabeledOther
+			switch s.Stmt.(type) {
+			case *ForStmt:
+				kind = LabeledLoop
+			case *SwitchStmt:
+				kind = LabeledSwitch
+			case *SelectStmt:
+				kind = LabeledSelect
+			}
+			list = append(list, LabeledInfo{s.Label, s.Stmt, kind})
+		}
+		return true
+	})
+	return list
+}
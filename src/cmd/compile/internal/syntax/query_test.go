@@ -0,0 +1,435 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestWalkSelectors(t *testing.T) {
+	const src = `package p
+
+func _() {
+	x.m()
+	f := x.n
+	(y.m)()
+	x.a.b(z.c)
+	defer w.Close()
+}
+`
+	f := mustParse(t, src, 0)
+
+	var got []string
+	WalkSelectors(f, func(sel *SelectorExpr, isCall bool) {
+		if IsMethodCall(sel, f) != isCall {
+			t.Errorf("%s: IsMethodCall = %v, want %v", String(sel), !isCall, isCall)
+		}
+		s := String(sel)
+		if isCall {
+			s += "()"
+		}
+		got = append(got, s)
+	})
+
+	const want = "x.m() x.n y.m() x.a.b() x.a z.c w.Close()"
+	if s := strings.Join(got, " "); s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+}
+
+func TestFindPair(t *testing.T) {
+	const src = `package p
+
+func f() int {
+	g := func() int {
+		if x {
+			return 1
+		}
+		return 2
+	}
+	return 3
+}
+`
+	f := mustParse(t, src, 0)
+
+	// find a return statement directly inside the body of a function literal
+	var lit *FuncLit
+	parent, child, found := FindPair(f, func(parent, child Node) bool {
+		if l, ok := parent.(*FuncLit); ok {
+			lit = l
+		}
+		if _, ok := child.(*ReturnStmt); ok {
+			return lit != nil && parent == lit.Body
+		}
+		return false
+	})
+	if !found {
+		t.Fatal("pair not found")
+	}
+	if parent != lit.Body {
+		t.Errorf("got parent %T, want function literal body", parent)
+	}
+	if got := String(child); got != "return 2" {
+		t.Errorf("got child %s, want return 2", got)
+	}
+
+	if _, _, found := FindPair(f, func(parent, child Node) bool { return false }); found {
+		t.Error("found pair, want none")
+	}
+}
+
+func TestEnclosingCase(t *testing.T) {
+	const src = `package p
+
+func _() {
+	a()
+	switch x {
+	case 1:
+		b()
+		select {
+		case <-ch:
+			c()
+		default:
+			if x {
+				d()
+			}
+		}
+	default:
+		func() { e() }()
+	}
+}
+`
+	f := mustParse(t, src, 0)
+	stmts := make(map[string]Stmt)
+	Inspect(f, func(n Node) bool {
+		if s, ok := n.(*ExprStmt); ok {
+			stmts[String(s)] = s
+		}
+		return true
+	})
+
+	for _, test := range []struct{ stmt, want string }{
+		{"a()", "<nil>"},
+		{"b()", "case 1"},
+		{"c()", "case <-ch"},
+		{"d()", "default"},
+		{"e()", "default"},
+	} {
+		c := EnclosingCase(f, stmts[test.stmt])
+		var got string
+		switch c := c.(type) {
+		case nil:
+			got = "<nil>"
+		case *CaseClause:
+			got = "default"
+			if c.Cases != nil {
+				got = "case " + String(c.Cases)
+			}
+		case *CommClause:
+			got = "default"
+			if c.Comm != nil {
+				got = "case " + String(c.Comm)
+			}
+		}
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.stmt, got, test.want)
+		}
+	}
+
+	if c := EnclosingCase(f, new(EmptyStmt)); c != nil {
+		t.Errorf("statement not in tree: got %v, want nil", c)
+	}
+}
+
+func TestParentMap(t *testing.T) {
+	f := mustParse(t, "package p; type T struct{ a, b int }; func f() { if x { return } }", 0)
+	parents := ParentMap(f)
+
+	if p, ok := parents[f]; !ok || p != nil {
+		t.Errorf("root: got parent %v (present: %v), want nil", p, ok)
+	}
+	count := 0
+	Inspect(f, func(n Node) bool {
+		if n == nil || n == f {
+			return true
+		}
+		count++
+		p := parents[n]
+		found := false
+		ChildrenFunc(p, func(c Node) bool {
+			found = found || c == n
+			return true
+		})
+		if !found {
+			t.Errorf("%s: parent %T does not contain it", String(n), p)
+		}
+		return true
+	})
+	if len(parents) != count { // root is not counted, the shared field type twice
+		t.Errorf("got %d entries, want %d", len(parents), count)
+	}
+
+	// the shared type int of fields a and b maps to the field a
+	st := typeExpr(t, f, "T").(*StructType)
+	if p := parents[st.FieldList[1].Type]; p != st.FieldList[0] {
+		t.Errorf("shared field type: got parent %v, want field a", p)
+	}
+}
+
+func TestReWalkSubtree(t *testing.T) {
+	f := mustParse(t, "package p; func f() { if x { a() } }; func g(a, b int) { b() }", 0)
+	parents := ParentMap(f)
+
+	// replace the body of f
+	fn := funcDecl(t, f, "f")
+	old := fn.Body
+	fn.Body = funcDecl(t, mustParse(t, "package p; func f() { for { c(d) } }", 0), "f").Body
+	var visited []string
+	ReWalkSubtree(old, fn.Body, parents, inspector(func(n Node) bool {
+		if n != nil {
+			visited = append(visited, nodeKind(n))
+		}
+		return true
+	}))
+	const want = "BlockStmt ForStmt BlockStmt ExprStmt CallExpr Name Name"
+	if got := strings.Join(visited, " "); got != want {
+		t.Errorf("visited %s, want %s", got, want)
+	}
+	if !maps.Equal(parents, ParentMap(f)) {
+		t.Errorf("parent map is not updated")
+	}
+
+	// modify the body of g in place
+	body := funcDecl(t, f, "g").Body
+	body.List = append(body.List, &ReturnStmt{})
+	ReWalkSubtree(body, body, parents, inspector(func(Node) bool { return true }))
+	if !maps.Equal(parents, ParentMap(f)) {
+		t.Errorf("parent map is not updated after in-place change")
+	}
+
+	// remove a statement from the body of g in place
+	stmt := body.List[0]
+	body.List = body.List[1:]
+	ReWalkSubtree(body, body, parents, inspector(func(Node) bool { return true }))
+	if _, ok := parents[stmt]; ok {
+		t.Errorf("removed statement is still in the parent map")
+	}
+	if !maps.Equal(parents, ParentMap(f)) {
+		t.Errorf("parent map is not updated after in-place removal")
+	}
+}
+
+func TestEnclosingFunc(t *testing.T) {
+	f := mustParse(t, "package p; var v = 1; func f() { g(func() { return }) }", 0)
+	parents := ParentMap(f)
+
+	var ret *ReturnStmt
+	var call *CallExpr
+	var lit *BasicLit
+	Inspect(f, func(n Node) bool {
+		switch n := n.(type) {
+		case *ReturnStmt:
+			ret = n
+		case *CallExpr:
+			call = n
+		case *BasicLit:
+			lit = n
+		}
+		return true
+	})
+
+	var kinds []string
+	for _, a := range Ancestors(parents, ret) {
+		kinds = append(kinds, nodeKind(a))
+	}
+	if got, want := strings.Join(kinds, " "), "BlockStmt FuncLit CallExpr ExprStmt BlockStmt FuncDecl File"; got != want {
+		t.Errorf("got ancestors %s, want %s", got, want)
+	}
+
+	if fn, ok := EnclosingFunc(parents, ret).(*FuncLit); !ok || call.ArgList[0] != fn {
+		t.Errorf("return: got %v, want function literal", fn)
+	}
+	if fn, ok := EnclosingFunc(parents, call).(*FuncDecl); !ok || fn.Name.Value != "f" {
+		t.Errorf("call: got %v, want func f", fn)
+	}
+	if fn := EnclosingFunc(parents, lit); fn != nil {
+		t.Errorf("var initializer: got %v, want nil", fn)
+	}
+}
+
+func TestCommonAncestor(t *testing.T) {
+	f := mustParse(t, "package p; func f() { a(); if x { b(); c(y) } }", 0)
+	fn := funcDecl(t, f, "f")
+	a := fn.Body.List[0]
+	ifs := fn.Body.List[1].(*IfStmt)
+	b, c := ifs.Then.List[0], ifs.Then.List[1]
+	y := c.(*ExprStmt).X.(*CallExpr).ArgList[0]
+
+	for _, test := range []struct {
+		ancestor, descendant Node
+		want                 bool
+	}{
+		{f, y, true},
+		{ifs, y, true},
+		{c, y, true},
+		{b, y, false},
+		{y, c, false},
+		{y, y, false},
+		{a, a, false},
+		{f, NewName(Pos{}, "z"), false},
+	} {
+		if got := IsAncestor(f, test.ancestor, test.descendant); got != test.want {
+			t.Errorf("IsAncestor(%s, %s) = %v, want %v", lineString(test.ancestor), lineString(test.descendant), got, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		a, b, want Node
+	}{
+		{b, y, ifs.Then},
+		{a, y, fn.Body},
+		{c, y, c},
+		{y, y, y},
+		{f, y, f},
+		{a, NewName(Pos{}, "z"), nil},
+	} {
+		if got := CommonAncestor(f, test.a, test.b); got != test.want {
+			t.Errorf("CommonAncestor(%s, %s) = %v, want %v", lineString(test.a), lineString(test.b), got, test.want)
+		}
+	}
+}
+
+func TestEnclosingBreakable(t *testing.T) {
+	const src = `package p
+
+func f() {
+	a()
+	for i := b(); i < c(); i++ {
+		switch d() {
+		case e():
+			g()
+		}
+		select {
+		case <-h():
+			k()
+		}
+		func() { m() }()
+		n()
+	}
+}
+`
+	f := mustParse(t, src, 0)
+	calls := make(map[string]*CallExpr)
+	Inspect(f, func(n Node) bool {
+		if call, ok := n.(*CallExpr); ok {
+			if name, ok := call.Fun.(*Name); ok {
+				calls[name.Value] = call
+			}
+		}
+		return true
+	})
+
+	for _, test := range []struct {
+		call, want string
+	}{
+		{"a", ""},
+		{"b", ""},
+		{"c", ""},
+		{"d", "for"},
+		{"e", "for"},
+		{"g", "switch"},
+		{"h", "for"},
+		{"k", "select"},
+		{"m", ""},
+		{"n", "for"},
+	} {
+		var got string
+		switch EnclosingBreakable(f, calls[test.call]).(type) {
+		case *ForStmt:
+			got = "for"
+		case *SwitchStmt:
+			got = "switch"
+		case *SelectStmt:
+			got = "select"
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.call, got, test.want)
+		}
+	}
+}
+
+func TestLabeledStatements(t *testing.T) {
+	const src = `package p
+
+func f() {
+A:
+	for {
+	B:
+		switch {
+		}
+	}
+C:
+	select {}
+D:
+	{
+	}
+E:
+	x++
+	_ = func() {
+	F:
+		for range s {
+		}
+	}
+	goto G
+G:
+}
+`
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, l := range LabeledStatements(funcDecl(t, f, "f")) {
+		got = append(got, fmt.Sprintf("%s:%d:%s", l.Label.Value, l.Kind, nodeKind(l.Stmt)))
+	}
+	const want = "A:1:ForStmt B:2:SwitchStmt C:3:SelectStmt D:0:BlockStmt E:0:AssignStmt F:1:ForStmt G:0:EmptyStmt"
+	if got := strings.Join(got, " "); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestFuncBounds(t *testing.T) {
+	const src = `package p
+
+func a() { x := 1; f(x); return }
+func b() { g() }
+func c() {}
+func d()
+`
+	f := mustParse(t, src, 0)
+	for _, test := range []struct {
+		name, first, last string
+	}{
+		{"a", "x := 1", "return"},
+		{"b", "g()", "g()"},
+		{"c", "", ""},
+		{"d", "", ""},
+	} {
+		first, last, ok := FuncBounds(funcDecl(t, f, test.name))
+		if ok != (test.first != "") {
+			t.Errorf("%s: got ok = %v", test.name, ok)
+			continue
+		}
+		if !ok {
+			if first != nil || last != nil {
+				t.Errorf("%s: got %v, %v, want nil statements", test.name, first, last)
+			}
+			continue
+		}
+		if got, got2 := String(first), String(last); got != test.first || got2 != test.last {
+			t.Errorf("%s: got %s, %s, want %s, %s", test.name, got, got2, test.first, test.last)
+		}
+	}
+}
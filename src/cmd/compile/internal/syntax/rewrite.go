@@ -0,0 +1,1263 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements source-level rewrites of syntax trees.
+
+package syntax
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SimplifyErrorf rewrites calls of the form errors.New(fmt.Sprintf(...))
+// into the equivalent fmt.Errorf(...) calls and returns the number of
+// calls rewritten. Only calls whose format is a string literal without a
+// %w verb are rewritten, since fmt.Errorf wraps the operand of %w while
+// fmt.Sprintf does not. The package names errors and fmt are matched
+// syntactically; renamed or shadowed imports are not recognized. If root
+// is a *File, an import of package errors that is not referred to anymore
+// after the rewrite is removed.
+func SimplifyErrorf(root Node) int {
+	count := 0
+	WalkAndChange(root, func(n *Node) bool {
+		if n == nil {
+			return true
+		}
+		call, ok := (*n).(*CallExpr)
+		if !ok || !isQualified(call.Fun, "errors", "New") || len(call.ArgList) != 1 || call.HasDots {
+			return true
+		}
+		arg, ok := Unparen(call.ArgList[0]).(*CallExpr)
+		if !ok || !isQualified(arg.Fun, "fmt", "Sprintf") || len(arg.ArgList) == 0 {
+			return true
+		}
+		lit, ok := Unparen(arg.ArgList[0]).(*BasicLit)
+		if !ok || lit.Kind != StringLit || lit.Bad {
+			return true
+		}
+		format, err := strconv.Unquote(lit.Value)
+		if err != nil || slices.ContainsFunc(parseFormat(format), func(v FormatVerb) bool { return v.Verb == 'w' }) {
+			return true
+		}
+		sel := arg.Fun.(*SelectorExpr)
+		sel.Sel = NewName(sel.Sel.Pos(), "Errorf")
+		*n = arg
+		count++
+		return true
+	})
+	if file, ok := root.(*File); ok && count > 0 {
+		removeUnusedImport(file, "errors", "errors")
+	}
+	return count
+}
+
+// removeUnusedImport removes the import declarations of file which import
+// path without renaming the package, if the package name pkg is not used
+// as the qualifier of a selector expression in file.
+func removeUnusedImport(file *File, path, pkg string) {
+	used := false
+	Inspect(file, func(n Node) bool {
+		if sel, ok := n.(*SelectorExpr); ok && isNameOf(sel.X, pkg) {
+			used = true
+		}
+		return !used
+	})
+	if used {
+		return
+	}
+	file.DeclList = slices.DeleteFunc(file.DeclList, func(d Decl) bool {
+		imp, ok := d.(*ImportDecl)
+		return ok && imp.LocalPkgName == nil && ImportPath(imp) == path
+	})
+}
+
+// isQualified reports whether x is the selector expression pkg.name
+// where pkg is a plain identifier.
+func isQualified(x Expr, pkg, name string) bool {
+	sel, ok := x.(*SelectorExpr)
+	if !ok || sel.Sel.Value != name {
+		return false
+	}
+	id, ok := sel.X.(*Name)
+	return ok && id.Value == pkg
+}
+
+// SimplifyBool simplifies the boolean expressions in the tree rooted at
+// root and returns the number of simplifications made: comparisons with
+// the boolean constants, x == true and x != false, become x, and
+// x == false and x != true become !x (with either operand order), and
+// double negations !!x become x. The rewrites are applied bottom-up, so
+// that, for instance, !(x == false) becomes x. The identifiers true and
+// false are assumed to denote the predeclared constants.
+func SimplifyBool(root Node) int {
+	count := 0
+	RewriteBottomUp(root, func(n Node) Node {
+		op, ok := n.(*Operation)
+		if !ok {
+			return n
+		}
+		switch op.Op {
+		case Not:
+			if x, ok := Unparen(op.X).(*Operation); ok && x.Op == Not && x.Y == nil {
+				count++
+				return x.X
+			}
+		case Eql, Neq:
+			x, lit := op.X, op.Y
+			if !isBoolConst(lit) {
+				x, lit = lit, x
+			}
+			if !isBoolConst(lit) || isBoolConst(x) {
+				return n // no constant operand, or both
+			}
+			count++
+			if (op.Op == Eql) == (Unparen(lit).(*Name).Value == "true") {
+				return x
+			}
+			return negate(op.Pos(), x, &count)
+		}
+		return n
+	})
+	return count
+}
+
+// isBoolConst reports whether x is the (possibly parenthesized)
+// identifier true or false.
+func isBoolConst(x Expr) bool {
+	x = Unparen(x)
+	return isNameOf(x, "true") || isNameOf(x, "false")
+}
+
+// negate returns the negation !x at pos, or y if x is !y, in which case
+// it increments count. Binary operations are parenthesized.
+func negate(pos Pos, x Expr, count *int) Expr {
+	if y, ok := Unparen(x).(*Operation); ok && y.Op == Not && y.Y == nil {
+		*count++
+		return y.X
+	}
+	if y, ok := x.(*Operation); ok && y.Y != nil {
+		p := new(ParenExpr)
+		p.pos = pos
+		p.X = x
+		x = p
+	}
+	not := new(Operation)
+	not.pos = pos
+	not.Op = Not
+	not.X = x
+	return not
+}
+
+// CollapseNestedIf returns an if statement equivalent to ifs, which
+// must be of the form
+//
+//	if a { if b { ... } }
+//
+// where neither if statement has an init statement or an else branch,
+// and the inner if statement is the only statement of the outer one, and
+// true. The result is
+//
+//	if a && b { ... }
+//
+// where a and b are parenthesized if they are || operations. The
+// result shares the conditions and the inner body with ifs, which
+// it is meant to replace. If ifs has a different form, the result
+// is nil and false.
+func CollapseNestedIf(ifs *IfStmt) (*IfStmt, bool) {
+	if ifs.Init != nil || ifs.Else != nil || len(ifs.Then.List) != 1 {
+		return nil, false
+	}
+	inner, ok := ifs.Then.List[0].(*IfStmt)
+	if !ok || inner.Init != nil || inner.Else != nil {
+		return nil, false
+	}
+
+	pos := ifs.Pos()
+	operand := func(x Expr) Expr {
+		if op, ok := x.(*Operation); ok && op.Op == OrOr {
+			p := new(ParenExpr)
+			p.pos = op.Pos()
+			p.X = x
+			return p
+		}
+		return x
+	}
+	cond := new(Operation)
+	cond.pos = pos
+	cond.Op = AndAnd
+	cond.X = operand(ifs.Cond)
+	cond.Y = operand(inner.Cond)
+
+	res := new(IfStmt)
+	res.pos = pos
+	res.Cond = cond
+	res.Then = inner.Then
+	return res, true
+}
+
+// MergeVarDecls merges each run of consecutive variable declaration
+// statements in block (but not in nested blocks) into a single grouped
+// declaration, and returns the number of declaration statements merged
+// into a preceding one. For instance,
+//
+//	var a int
+//	var b, c = 1, 2
+//	var (
+//		d string
+//	)
+//
+// becomes
+//
+//	var (
+//		a int
+//		b, c = 1, 2
+//		d string
+//	)
+//
+// Declarations keep their order and initializers; runs are interrupted
+// by any other statement, including constant or type declarations.
+func MergeVarDecls(block *BlockStmt) int {
+	count := 0
+	var list []Stmt
+	var prev *DeclStmt // preceding variable declaration statement, if any
+	for _, s := range block.List {
+		d, ok := s.(*DeclStmt)
+		if !ok || !isVarDeclStmt(d) {
+			list = append(list, s)
+			prev = nil
+			continue
+		}
+		if prev == nil {
+			list = append(list, s)
+			prev = d
+			continue
+		}
+		group := prev.DeclList[0].(*VarDecl).Group
+		if group == nil {
+			group = new(Group)
+		}
+		prev.DeclList = append(prev.DeclList, d.DeclList...)
+		for _, x := range prev.DeclList {
+			x.(*VarDecl).Group = group
+		}
+		count++
+	}
+	block.List = list
+	return count
+}
+
+// isVarDeclStmt reports whether d consists of variable declarations.
+func isVarDeclStmt(d *DeclStmt) bool {
+	for _, x := range d.DeclList {
+		if _, ok := x.(*VarDecl); !ok {
+			return false
+		}
+	}
+	return len(d.DeclList) > 0
+}
+
+// RenameDecl renames the identifier decl declared in fn, and all uses
+// of the object it denotes within fn, to newName, and returns the number
+// of identifiers renamed (including decl). Identifiers with the same name
+// which denote other objects, for instance because they are declared in
+// an inner scope, are not renamed. RenameDecl does not check whether
+// newName conflicts with other identifiers. The result is 0 if decl is
+// not declared in fn.
+func RenameDecl(fn *FuncDecl, decl *Name, newName string) int {
+	var list []*Name
+	r := resolver{
+		declare: func(name *Name, owner Node) {
+			if name == decl {
+				list = append(list, name)
+			}
+		},
+		use: func(name, d *Name) {
+			if d == decl {
+				list = append(list, name)
+			}
+		},
+	}
+	r.resolve(fn)
+
+	if len(list) == 0 || list[0] != decl {
+		return 0 // not declared in fn
+	}
+	for _, name := range list {
+		name.Value = newName
+	}
+	return len(list)
+}
+
+// RangeToIndexed returns a three-clause for loop equivalent to the
+// range loop, which must be of the form
+//
+//	for [i [, v] :=] range x { ... }
+//
+// where x is a (possibly qualified) identifier, and true. The result is
+//
+//	for i := 0; i < len(x); i++ { v := x[i]; ... }
+//
+// where the index variable is given a fresh name if there is none (or
+// it is the blank identifier), and the assignment to v is omitted if
+// there is no (non-blank) v. The result shares the body statements and
+// identifiers of loop, and branch statement targets referring to loop
+// are adjusted to the result, which is meant to replace loop. Since
+// there is no type information, it is the caller's responsibility to
+// ensure that x is a slice or array, which is not reassigned by the
+// loop body, and that len is not shadowed. If loop has a different
+// form, the result is nil and false.
+func RangeToIndexed(loop *ForStmt) (*ForStmt, bool) {
+	r, ok := loop.Init.(*RangeClause)
+	if !ok || r.Lhs != nil && !r.Def || !isStableOperand(r.X) {
+		return nil, false
+	}
+
+	var key, val *Name
+	switch lhs := UnpackListExpr(r.Lhs); len(lhs) {
+	case 2:
+		if val, ok = lhs[1].(*Name); !ok {
+			return nil, false
+		}
+		if val.Value == "_" {
+			val = nil
+		}
+		fallthrough
+	case 1:
+		if key, ok = lhs[0].(*Name); !ok {
+			return nil, false
+		}
+	}
+
+	pos := loop.Pos()
+	if key == nil || key.Value == "_" {
+		key = NewName(pos, freshName(loop, "i"))
+	}
+	index := func() Expr { return NewName(pos, key.Value) }
+
+	body := new(BlockStmt)
+	body.pos = loop.Body.Pos()
+	body.Rbrace = loop.Body.Rbrace
+	if val != nil {
+		elem := new(IndexExpr)
+		elem.pos = pos
+		elem.X = Clone(r.X)
+		elem.Index = index()
+		body.List = append(body.List, newAssignStmt(pos, Def, val, elem))
+	}
+	body.List = append(body.List, loop.Body.List...)
+
+	length := new(CallExpr)
+	length.pos = pos
+	length.Fun = NewName(pos, "len")
+	length.ArgList = []Expr{Clone(r.X)}
+
+	cond := new(Operation)
+	cond.pos = pos
+	cond.Op = Lss
+	cond.X = index()
+	cond.Y = length
+
+	res := new(ForStmt)
+	res.pos = pos
+	res.Init = newAssignStmt(pos, Def, key, newBasicLit(pos, "0", IntLit))
+	res.Cond = cond
+	res.Post = newAssignStmt(pos, Add, index(), nil)
+	res.Body = body
+	retarget(body, loop, res)
+	return res, true
+}
+
+// IndexedToRange returns a range loop equivalent to the three-clause
+// for loop, which must be of the form
+//
+//	for i := 0; i < len(x); i++ { ... }
+//
+// where x is a (possibly qualified) identifier and i++ may also be
+// written as i += 1, and true. The result is
+//
+//	for i := range x { ... }
+//
+// The result shares the body of loop, and branch statement targets
+// referring to loop are adjusted to the result, which is meant to
+// replace loop. The loop body must not assign to i or take its address;
+// otherwise, or if loop has a different form, the result is nil and
+// false. As with RangeToIndexed, it is the caller's responsibility to
+// ensure that x is a slice or array which is not reassigned by the loop
+// body, and that len is not shadowed.
+func IndexedToRange(loop *ForStmt) (*ForStmt, bool) {
+	init, ok := loop.Init.(*AssignStmt)
+	if !ok || init.Op != Def {
+		return nil, false
+	}
+	key, ok := init.Lhs.(*Name)
+	if !ok || key.Value == "_" {
+		return nil, false
+	}
+	if lit, ok := init.Rhs.(*BasicLit); !ok || lit.Kind != IntLit || lit.Value != "0" {
+		return nil, false
+	}
+
+	cond, ok := loop.Cond.(*Operation)
+	if !ok || cond.Op != Lss || !isNameOf(cond.X, key.Value) {
+		return nil, false
+	}
+	length, ok := cond.Y.(*CallExpr)
+	if !ok || !isNameOf(length.Fun, "len") || len(length.ArgList) != 1 || length.HasDots || !isStableOperand(length.ArgList[0]) {
+		return nil, false
+	}
+
+	post, ok := loop.Post.(*AssignStmt)
+	if !ok || post.Op != Add || !isNameOf(post.Lhs, key.Value) {
+		return nil, false
+	}
+	if post.Rhs != nil {
+		if lit, ok := post.Rhs.(*BasicLit); !ok || lit.Kind != IntLit || lit.Value != "1" {
+			return nil, false
+		}
+	}
+
+	if modifiesVar(loop.Body, key.Value) {
+		return nil, false
+	}
+
+	r := new(RangeClause)
+	r.pos = loop.Pos()
+	r.Lhs = key
+	r.Def = true
+	r.X = length.ArgList[0]
+
+	res := new(ForStmt)
+	res.pos = loop.Pos()
+	res.Init = r
+	res.Body = loop.Body
+	retarget(res.Body, loop, res)
+	return res, true
+}
+
+// isStableOperand reports whether x is a (possibly parenthesized)
+// identifier or a selector expression of the form a.b.c, whose
+// evaluation has no side effects.
+func isStableOperand(x Expr) bool {
+	switch x := Unparen(x).(type) {
+	case *Name:
+		return true
+	case *SelectorExpr:
+		return isStableOperand(x.X)
+	}
+	return false
+}
+
+// modifiesVar reports whether the variable named name is assigned
+// to, incremented or decremented, or has its address taken in the
+// tree rooted at root, regardless of scope.
+func modifiesVar(root Node, name string) bool {
+	found := false
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *AssignStmt:
+			found = found || slices.ContainsFunc(UnpackListExpr(n.Lhs), func(x Expr) bool {
+				return isNameOf(Unparen(x), name)
+			})
+		case *RangeClause:
+			found = found || !n.Def && slices.ContainsFunc(UnpackListExpr(n.Lhs), func(x Expr) bool {
+				return isNameOf(Unparen(x), name)
+			})
+		case *Operation:
+			found = found || n.Op == And && n.Y == nil && isNameOf(Unparen(n.X), name)
+		}
+		return !found
+	})
+	return found
+}
+
+// freshName returns a name based on the given name which
+// is not used as an identifier in the tree rooted at root.
+func freshName(root Node, name string) string {
+	used := make(map[string]bool)
+	Inspect(root, func(n Node) bool {
+		if n, ok := n.(*Name); ok {
+			used[n.Value] = true
+		}
+		return true
+	})
+	s := name
+	for i := 1; used[s]; i++ {
+		s = name + strconv.Itoa(i)
+	}
+	return s
+}
+
+// retarget changes the targets of the branch statements
+// in the tree rooted at root from from to to.
+func retarget(root Node, from, to Stmt) {
+	Inspect(root, func(n Node) bool {
+		if b, ok := n.(*BranchStmt); ok && b.Target == from {
+			b.Target = to
+		}
+		return true
+	})
+}
+
+func newAssignStmt(pos Pos, op Operator, lhs, rhs Expr) *AssignStmt {
+	a := new(AssignStmt)
+	a.pos = pos
+	a.Op = op
+	a.Lhs = lhs
+	a.Rhs = rhs
+	return a
+}
+
+// ExtractBlock moves the statements of list, which must be a non-empty
+// sequence of consecutive statements of a block or case clause in the
+// body of fn, into the body of a new function called newName and
+// replaces them with a call of that function. The free identifiers of
+// the statements (see FreeIdents) become the parameters of the new
+// function, with the types of their declarations, and the arguments
+// of the call. ExtractBlock returns the new function declaration, which
+// the caller must add to the file, and the call, whose results the
+// caller may wire up if the new function is changed to return values.
+//
+// ExtractBlock fails and leaves fn unchanged if list is not such a
+// sequence, if the type of a free identifier is not explicitly declared
+// (for instance, because it is declared with :=, or it is a constant,
+// type, or type parameter), or if control may leave the statements other
+// than by completing them normally (because they contain a return or
+// fallthrough statement, or a branch statement to a label outside of
+// them). It also fails if a label declared in the statements is used
+// outside of them, in fn (including function literals). Since the
+// parameters are passed by value, it fails as well if the statements
+// assign to a free variable (directly or through a selector, index, or
+// slice expression) or take its address, and, since the statements'
+// declarations go out of scope, if an identifier declared in the
+// statements is referred to after them.
+//
+// Without type information, modifications of free variables through
+// method calls with pointer receivers are not detected. Deferred calls
+// in the statements run when the new function returns.
+func ExtractBlock(fn *FuncDecl, list []Stmt, newName string) (*FuncDecl, *CallExpr, bool) {
+	if fn.Body == nil || len(list) == 0 {
+		return nil, nil, false
+	}
+
+	// find the statement list containing list
+	var stmts *[]Stmt
+	var index int
+	Inspect(fn.Body, func(n Node) bool {
+		var l *[]Stmt
+		switch n := n.(type) {
+		case *BlockStmt:
+			l = &n.List
+		case *CaseClause:
+			l = &n.Body
+		case *CommClause:
+			l = &n.Body
+		}
+		if l != nil {
+			if i := slices.Index(*l, list[0]); i >= 0 && i+len(list) <= len(*l) && slices.Equal((*l)[i:i+len(list)], list) {
+				stmts, index = l, i
+			}
+		}
+		return stmts == nil
+	})
+	if stmts == nil || !isExtractable(fn, list) {
+		return nil, nil, false
+	}
+
+	// types maps the identifiers declared with an explicit type to
+	// their types; the types of variadic parameters are slice types
+	types := make(map[*Name]Expr)
+	tparams := make(map[*Field]bool)
+	for _, f := range fn.TParamList {
+		tparams[f] = true
+	}
+	Inspect(fn, func(n Node) bool {
+		switch n := n.(type) {
+		case *Field:
+			if n.Name != nil && !tparams[n] {
+				types[n.Name] = n.Type
+				if t, ok := n.Type.(*DotsType); ok {
+					s := new(SliceType)
+					s.pos = t.Pos()
+					s.Elem = t.Elem
+					types[n.Name] = s
+				}
+			}
+		case *VarDecl:
+			if n.Type != nil {
+				for _, name := range n.NameList {
+					types[name] = n.Type
+				}
+			}
+		}
+		return true
+	})
+
+	free := FreeIdents(fn, list)
+	if !isolatedStmts(fn, list, free) {
+		return nil, nil, false
+	}
+
+	pos := list[0].Pos()
+	params := make([]*Field, len(free))
+	args := make([]Expr, len(free))
+	for i, name := range free {
+		typ := types[name]
+		if typ == nil {
+			return nil, nil, false
+		}
+		f := new(Field)
+		f.pos = pos
+		f.Name = NewName(pos, name.Value)
+		f.Type = Clone(typ)
+		params[i] = f
+		args[i] = NewName(pos, name.Value)
+	}
+
+	ftype := new(FuncType)
+	ftype.pos = pos
+	ftype.ParamList = params
+	body := new(BlockStmt)
+	body.pos = pos
+	body.List = slices.Clone(list)
+	body.Rbrace = EndPos(list[len(list)-1])
+	decl := new(FuncDecl)
+	decl.pos = pos
+	decl.Name = NewName(pos, newName)
+	decl.Type = ftype
+	decl.Body = body
+
+	call := new(CallExpr)
+	call.pos = pos
+	call.Fun = NewName(pos, newName)
+	if len(args) > 0 {
+		call.ArgList = args
+	}
+	s := new(ExprStmt)
+	s.pos = pos
+	s.X = call
+	*stmts = slices.Replace(*stmts, index, index+len(list), Stmt(s))
+
+	return decl, call, true
+}
+
+// isolatedStmts reports whether the statements of list, which are part
+// of fn and refer to the free identifiers free (see FreeIdents), neither
+// assign to a free variable nor take its address, and whether the
+// identifiers declared in the statements are not referred to outside
+// of them.
+func isolatedStmts(fn *FuncDecl, list []Stmt, free []*Name) bool {
+	inside := make(map[*Name]bool)
+	for _, s := range list {
+		Inspect(s, func(n Node) bool {
+			if n, ok := n.(*Name); ok {
+				inside[n] = true
+			}
+			return true
+		})
+	}
+
+	ok := true
+	decls := make(map[*Name]*Name) // use -> declaration
+	r := resolver{
+		use: func(name, decl *Name) {
+			decls[name] = decl
+			if decl != nil && inside[decl] && !inside[name] {
+				ok = false // declared in list, used outside
+			}
+		},
+	}
+	r.resolve(fn)
+	if !ok {
+		return false
+	}
+
+	isFree := func(n *Name) bool {
+		return inside[n] && slices.Contains(free, decls[n])
+	}
+	for _, s := range list {
+		if writesVar(s, Pos{}, isFree) {
+			return false
+		}
+	}
+	return true
+}
+
+// isExtractable reports whether control cannot leave the statements of
+// list, which are part of fn, other than by completing them normally,
+// and no label declared in the statements is used elsewhere in fn.
+func isExtractable(fn *FuncDecl, list []Stmt) bool {
+	// collect the labels declared in list
+	labels := make(map[string]bool)
+	for _, s := range list {
+		Inspect(s, func(n Node) bool {
+			switch n := n.(type) {
+			case *FuncLit:
+				return false
+			case *LabeledStmt:
+				labels[n.Label.Value] = true
+			}
+			return true
+		})
+	}
+
+	ok := true
+	var stack []Node // enclosing nodes within list
+	for _, s := range list {
+		Inspect(s, func(n Node) bool {
+			switch n := n.(type) {
+			case nil:
+				stack = stack[:len(stack)-1]
+				return false
+			case *FuncLit:
+				return false
+			case *ReturnStmt:
+				ok = false
+			case *BranchStmt:
+				switch {
+				case n.Tok == _Fallthrough:
+					ok = false
+				case n.Label != nil:
+					ok = ok && labels[n.Label.Value]
+				default:
+					ok = ok && slices.ContainsFunc(stack, func(m Node) bool {
+						switch m.(type) {
+						case *ForStmt:
+							return true
+						case *SwitchStmt, *SelectStmt:
+							return n.Tok == _Break
+						}
+						return false
+					})
+				}
+			}
+			stack = append(stack, n)
+			return true
+		})
+	}
+	if !ok {
+		return false
+	}
+
+	// labels declared in list must not be used outside of it
+	inside := make(map[Stmt]bool)
+	for _, s := range list {
+		inside[s] = true
+	}
+	Inspect(fn.Body, func(n Node) bool {
+		if s, ok := n.(Stmt); ok && inside[s] {
+			return false
+		}
+		if b, isBranch := n.(*BranchStmt); isBranch && b.Label != nil && labels[b.Label.Value] {
+			ok = false
+		}
+		return ok
+	})
+	return ok
+}
+
+// AddFirstParam adds a parameter with the given name and type typ
+// before the first parameter of fn, as needed, for instance, to add
+// a ctx context.Context parameter. Since parameters must either all
+// be named or all be unnamed, existing unnamed parameters are named _.
+// The type typ becomes part of fn; it must not be shared with other
+// parts of the tree. Use UpdateCallSites to update the calls of fn.
+func AddFirstParam(fn *FuncDecl, name string, typ Expr) {
+	pos := fn.Type.Pos()
+	for _, p := range fn.Type.ParamList {
+		if p.Name == nil {
+			p.Name = NewName(p.Pos(), "_")
+		}
+	}
+	f := new(Field)
+	f.pos = pos
+	f.Name = NewName(pos, name)
+	f.Type = typ
+	fn.Type.ParamList = append([]*Field{f}, fn.Type.ParamList...)
+}
+
+// UpdateCallSites inserts a copy of arg before the first argument
+// of each call of the function or method fnName in the tree rooted
+// at root, and returns the number of calls updated. Calls are
+// recognized by name (see CallFanOut); as there is no type
+// information, calls of distinct functions or methods with the same
+// name are updated as well.
+func UpdateCallSites(root Node, fnName string, arg Expr) int {
+	count := 0
+	Inspect(root, func(n Node) bool {
+		if call, ok := n.(*CallExpr); ok {
+			if name := calleeName(call); name != nil && name.Value == fnName {
+				call.ArgList = append([]Expr{Clone(arg)}, call.ArgList...)
+				count++
+			}
+		}
+		return true
+	})
+	return count
+}
+
+// ReorderParams reorders the parameters of fn according to perm, which
+// must be a permutation of the parameter indices: the i'th parameter of
+// the result is the parameter perm[i] of fn. Parameters declared together,
+// as in a, b int, share their type; a group is split into parameters with
+// their own copy of the type where the permutation separates its members,
+// so that the parameters of the result can be grouped independently. The
+// result is false and fn is unchanged if perm is not a permutation of the
+// parameter indices, or if it moves a variadic parameter from the last
+// position. Use ReorderArgs to update the calls of fn.
+func ReorderParams(fn *FuncDecl, perm []int) bool {
+	params := fn.Type.ParamList
+	if !isPerm(perm, len(params)) {
+		return false
+	}
+	if n := len(params); n > 0 && perm[n-1] != n-1 {
+		if _, ok := params[n-1].Type.(*DotsType); ok {
+			return false
+		}
+	}
+
+	list := make([]*Field, len(params))
+	for i, j := range perm {
+		list[i] = params[j]
+	}
+	types := make(map[Expr]Expr) // type of a group -> type of its latest run in list
+	for i, f := range list {
+		typ, ok := types[f.Type]
+		switch {
+		case !ok:
+			typ = f.Type
+		case list[i-1].Type != typ:
+			typ = Clone(f.Type)
+		}
+		types[f.Type] = typ
+		f.Type = typ
+	}
+	fn.Type.ParamList = list
+	return true
+}
+
+// ReorderArgs reorders the arguments of each call of the function or
+// method fnName in the tree rooted at root according to perm (see
+// ReorderParams), and returns the number of calls updated. Calls are
+// recognized by name, as by UpdateCallSites. If the last parameter is
+// not moved, calls with additional arguments (of a variadic function)
+// are updated as well, keeping the trailing arguments in place; other
+// calls with a different number of arguments than len(perm), or with a
+// spread argument f(x...) that would be moved, are not updated. Since the
+// arguments are evaluated in the new order, it is the caller's
+// responsibility to ensure that their order of evaluation does not matter.
+func ReorderArgs(root Node, fnName string, perm []int) int {
+	n := len(perm)
+	if !isPerm(perm, n) {
+		return 0
+	}
+	fixed := n // number of arguments subject to the permutation
+	if n > 0 && perm[n-1] == n-1 {
+		fixed = n - 1
+	}
+	count := 0
+	Inspect(root, func(x Node) bool {
+		call, ok := x.(*CallExpr)
+		if !ok {
+			return true
+		}
+		if name := calleeName(call); name == nil || name.Value != fnName {
+			return true
+		}
+		args := call.ArgList
+		if call.HasDots && fixed < n && len(args) == n || !call.HasDots && (len(args) == n || fixed < n && len(args) >= fixed) {
+			list := slices.Clone(args)
+			for i, j := range perm[:fixed] {
+				list[i] = args[j]
+			}
+			call.ArgList = list
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// isPerm reports whether perm is a permutation of 0, 1, ... n-1.
+func isPerm(perm []int, n int) bool {
+	if len(perm) != n {
+		return false
+	}
+	seen := make([]bool, n)
+	for _, i := range perm {
+		if i < 0 || i >= n || seen[i] {
+			return false
+		}
+		seen[i] = true
+	}
+	return true
+}
+
+// InlineVar inlines the local variable declared by decl in fn: if decl
+// is declared by a statement of the form x := init or var x = init, and
+// the variable is used exactly once, the use is replaced by (a copy of)
+// init and the declaration is deleted. The initializer is parenthesized
+// if it is a unary or binary operation. The result is true if the
+// variable was inlined; otherwise fn is unchanged and the error
+// describes the reason.
+//
+// InlineVar refuses to inline the variable if the initializer is not pure
+// (see IsPure) or contains a composite literal (which would allocate a new
+// value at the use, as for p := &T{}), if the use is in a loop or function
+// literal not containing the declaration (where the initializer would be
+// evaluated repeatedly or later), if the variable is assigned to or has
+// its address taken, or if a variable referred to by the initializer is
+// modified (assigned to, including through selectors and index
+// expressions, or has its address taken) after the initializer, or would
+// refer to a different object at the place of the use. Without type
+// information, modifications through other pointers are not detected;
+// also, inlining a constant initializer may change its type, as in
+// x := 1; f(x) where f accepts a float64.
+func InlineVar(fn *FuncDecl, decl *Name) (bool, error) {
+	if fn.Body == nil {
+		return false, fmt.Errorf("function %s has no body", fn.Name.Value)
+	}
+
+	// find the declaring statement and its initializer
+	stmt, init := varDeclStmt(fn.Body, decl)
+	if stmt == nil {
+		return false, fmt.Errorf("%s is not declared with an initializer by a statement in a block", decl.Value)
+	}
+	if !IsPure(init) {
+		return false, fmt.Errorf("initializer of %s is not pure", decl.Value)
+	}
+	hasLit := false
+	Inspect(init, func(n Node) bool {
+		if _, ok := n.(*CompositeLit); ok {
+			hasLit = true
+		}
+		return !hasLit
+	})
+	if hasLit {
+		return false, fmt.Errorf("initializer of %s contains a composite literal", decl.Value)
+	}
+
+	// find the uses of the variable and the declarations
+	// of the names in the initializer
+	var uses []*Name
+	decls := make(map[*Name]*Name) // use -> declaration
+	r := resolver{
+		use: func(name, d *Name) {
+			decls[name] = d
+			if d == decl {
+				uses = append(uses, name)
+			}
+		},
+	}
+	r.resolve(fn)
+	switch {
+	case len(uses) == 0:
+		return false, fmt.Errorf("%s is not used", decl.Value)
+	case len(uses) > 1:
+		return false, fmt.Errorf("%s is used more than once", decl.Value)
+	}
+	use := uses[0]
+	parents := ParentMap(fn.Body)
+	outer := make(map[Node]bool) // stmt and its ancestors
+	for n := Node(stmt); n != nil; n = parents[n] {
+		outer[n] = true
+	}
+	for n := parents[use]; n != nil && !outer[n]; n = parents[n] {
+		switch n.(type) {
+		case *ForStmt:
+			return false, fmt.Errorf("%s is used in a loop not containing its declaration", decl.Value)
+		case *FuncLit:
+			return false, fmt.Errorf("%s is used in a function literal not containing its declaration", decl.Value)
+		}
+	}
+	if writesVar(fn.Body, Pos{}, func(n *Name) bool { return decls[n] == decl }) {
+		return false, fmt.Errorf("%s is assigned to or has its address taken", decl.Value)
+	}
+	inInit := make(map[*Name]bool) // names in the initializer
+	Inspect(init, func(n Node) bool {
+		if n, ok := n.(*Name); ok {
+			inInit[n] = true
+		}
+		return true
+	})
+	var modified *Name // variable in the initializer modified after the declaration
+	Inspect(init, func(n Node) bool {
+		if n, ok := n.(*Name); ok && modified == nil {
+			if d, ok := decls[n]; ok && writesVar(fn.Body, stmt.Pos(), func(m *Name) bool {
+				return m.Value == n.Value && decls[m] == d && !inInit[m]
+			}) {
+				modified = n
+			}
+		}
+		return modified == nil
+	})
+	if modified != nil {
+		return false, fmt.Errorf("%s is modified after the declaration of %s", modified.Value, decl.Value)
+	}
+
+	// replace the use
+	x := Clone(init)
+	if op, ok := x.(*Operation); ok {
+		p := new(ParenExpr)
+		p.pos = op.Pos()
+		p.X = x
+		x = p
+	}
+	replace := func(from, to Expr) {
+		WalkAndChange(fn.Body, func(n *Node) bool {
+			if n != nil && *n == from {
+				*n = to
+				return false
+			}
+			return true
+		})
+	}
+	replace(use, x)
+
+	// verify that the names in the copy refer to the same objects
+	var copies []*Name
+	Inspect(x, func(n Node) bool {
+		if n, ok := n.(*Name); ok {
+			copies = append(copies, n)
+		}
+		return true
+	})
+	orig := make(map[*Name]*Name) // copy -> original
+	i := 0
+	Inspect(init, func(n Node) bool {
+		if n, ok := n.(*Name); ok {
+			orig[copies[i]] = n
+			i++
+		}
+		return true
+	})
+	var captured *Name
+	r = resolver{
+		use: func(name, d *Name) {
+			if o, ok := orig[name]; ok && d != decls[o] && captured == nil {
+				captured = o
+			}
+		},
+	}
+	r.resolve(fn)
+	if captured != nil {
+		replace(x, use)
+		return false, fmt.Errorf("%s refers to a different object at the use of %s", captured.Value, decl.Value)
+	}
+
+	deleteStmt(fn.Body, stmt)
+	return true, nil
+}
+
+// varDeclStmt returns the statement of the form x := init or var x = init
+// declaring decl in a statement list in the tree rooted at root, and the
+// initializer, or nil.
+func varDeclStmt(root Node, decl *Name) (stmt Stmt, init Expr) {
+	check := func(list []Stmt) {
+		for _, s := range list {
+			switch s := s.(type) {
+			case *AssignStmt:
+				if s.Op == Def && s.Lhs == decl && s.Rhs != nil {
+					if _, ok := s.Rhs.(*ListExpr); !ok {
+						stmt, init = s, s.Rhs
+					}
+				}
+			case *DeclStmt:
+				if len(s.DeclList) != 1 {
+					break
+				}
+				if d, ok := s.DeclList[0].(*VarDecl); ok && len(d.NameList) == 1 && d.NameList[0] == decl && d.Type == nil && d.Values != nil {
+					stmt, init = s, d.Values
+				}
+			}
+		}
+	}
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *BlockStmt:
+			check(n.List)
+		case *CaseClause:
+			check(n.Body)
+		case *CommClause:
+			check(n.Body)
+		}
+		return stmt == nil
+	})
+	return
+}
+
+// deleteStmt deletes the statement stmt from the
+// statement list containing it in the tree rooted at root.
+func deleteStmt(root Node, stmt Stmt) {
+	del := func(list []Stmt) []Stmt {
+		return slices.DeleteFunc(list, func(s Stmt) bool { return s == stmt })
+	}
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *BlockStmt:
+			n.List = del(n.List)
+		case *CaseClause:
+			n.Body = del(n.Body)
+		case *CommClause:
+			n.Body = del(n.Body)
+		}
+		return true
+	})
+}
+
+// writesVar reports whether a variable identified by isVar is assigned
+// to (directly or through a selector, index, slice, or indirection
+// expression), or has its address taken, in the tree rooted at root, at
+// a position after the position after, if known.
+func writesVar(root Node, after Pos, isVar func(*Name) bool) bool {
+	found := false
+	write := func(pos Pos, x Expr) {
+		if after.IsKnown() && pos.Cmp(after) <= 0 {
+			return
+		}
+		if name := writtenName(x); name != nil && isVar(name) {
+			found = true
+		}
+	}
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *AssignStmt:
+			for _, x := range UnpackListExpr(n.Lhs) {
+				write(n.Pos(), x)
+			}
+		case *RangeClause:
+			if !n.Def {
+				for _, x := range UnpackListExpr(n.Lhs) {
+					write(n.Pos(), x)
+				}
+			}
+		case *Operation:
+			if n.Op == And && n.Y == nil {
+				write(n.Pos(), n.X)
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// writtenName returns the identifier x, or the identifier of the operand of
+// the selector, index, slice, or indirection expression x, recursively,
+// or nil.
+func writtenName(x Expr) *Name {
+	for {
+		switch y := x.(type) {
+		case *Name:
+			return y
+		case *ParenExpr:
+			x = y.X
+		case *SelectorExpr:
+			x = y.X
+		case *IndexExpr:
+			x = y.X
+		case *SliceExpr:
+			x = y.X
+		case *Operation:
+			if y.Op != Mul || y.Y != nil {
+				return nil
+			}
+			x = y.X
+		default:
+			return nil
+		}
+	}
+}
+
+// WrapErrorReturns rewrites the return statements of fn whose last result
+// is the identifier err, as in return x, err, to return the error wrapped
+// with a call fmt.Errorf(format, err), and returns the number of return
+// statements rewritten. If format does not contain the verb %w, ": %w" is
+// appended to it; for instance, the format "reading config" turns
+// return nil, err into
+//
+//	return nil, fmt.Errorf("reading config: %w", err)
+//
+// The other results are retained. Return statements of function literals,
+// which do not return from fn, are not rewritten. It is the caller's
+// responsibility to ensure that fmt refers to the imported package fmt,
+// and that err is not nil at the rewritten return statements, since the
+// result of wrapping a nil error is not nil.
+func WrapErrorReturns(fn *FuncDecl, format string) int {
+	if fn.Body == nil {
+		return 0
+	}
+	if !strings.Contains(format, "%w") {
+		format += ": %w"
+	}
+	count := 0
+	Inspect(fn.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case *FuncLit:
+			return false
+		case *ReturnStmt:
+			results := UnpackListExpr(n.Results)
+			if len(results) == 0 || !isNameOf(results[len(results)-1], "err") {
+				break
+			}
+			err := results[len(results)-1]
+			pos := err.Pos()
+			sel := new(SelectorExpr)
+			sel.pos = pos
+			sel.X = NewName(pos, "fmt")
+			sel.Sel = NewName(pos, "Errorf")
+			call := new(CallExpr)
+			call.pos = pos
+			call.Fun = sel
+			call.ArgList = []Expr{newBasicLit(pos, strconv.Quote(format), StringLit), err}
+			if list, ok := n.Results.(*ListExpr); ok {
+				list.ElemList[len(list.ElemList)-1] = call
+			} else {
+				n.Results = call
+			}
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// KeyStructLit rewrites the struct literal cl with unkeyed elements,
+// as in T{1, "a"}, into the equivalent literal with keyed elements, as in
+// T{x: 1, y: "a"}, pairing each element with the field name at the same
+// index in fieldNames, which must list the names of all fields of the
+// struct type in declaration order. Since there is no type information,
+// the field names must be provided by the caller. The result is false
+// and cl is unchanged if cl has no elements or all its elements are
+// keyed already; it is false with an error if cl mixes keyed and unkeyed
+// elements, if the number of elements and field names differ, or if a
+// field name is blank, since blank fields cannot be keyed.
+func KeyStructLit(cl *CompositeLit, fieldNames []string) (bool, error) {
+	if len(cl.ElemList) == 0 || cl.NKeys == len(cl.ElemList) {
+		return false, nil
+	}
+	if slices.ContainsFunc(cl.ElemList, func(x Expr) bool { return !isUnkeyed(x) }) {
+		return false, fmt.Errorf("literal mixes keyed and unkeyed elements")
+	}
+	if len(fieldNames) != len(cl.ElemList) {
+		return false, fmt.Errorf("literal has %d elements, but %d field names are given", len(cl.ElemList), len(fieldNames))
+	}
+	for _, name := range fieldNames {
+		if name == "" || name == "_" {
+			return false, fmt.Errorf("field name %q cannot be used as a key", name)
+		}
+	}
+
+	for i, x := range cl.ElemList {
+		pos := StartPos(x)
+		kv := new(KeyValueExpr)
+		kv.pos = pos
+		kv.Key = NewName(pos, fieldNames[i])
+		kv.Value = x
+		cl.ElemList[i] = kv
+	}
+	cl.NKeys = len(cl.ElemList)
+	return true, nil
+}
@@ -0,0 +1,576 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+// lineString prints n in LineForm and returns the printed string.
+func lineString(n Node) string {
+	var buf strings.Builder
+	Fprint(&buf, n, LineForm)
+	return buf.String()
+}
+
+// testRewrite parses the source src, applies rewrite to it and compares
+// the result, printed in LineForm, against want. The count returned by
+// rewrite must match wantCount.
+func testRewrite(t *testing.T, src, want string, wantCount int, rewrite func(*File) int) {
+	t.Helper()
+	f := mustParse(t, src, 0)
+	count := rewrite(f)
+	if got := lineString(f); got != want {
+		t.Errorf("%s:\ngot  %s\nwant %s", src, got, want)
+	}
+	if count != wantCount {
+		t.Errorf("%s: got count %d, want %d", src, count, wantCount)
+	}
+}
+
+func TestSimplifyErrorf(t *testing.T) {
+	for _, test := range []struct {
+		src, want string
+		count     int
+	}{
+		{`package p; var _ = errors.New(fmt.Sprintf("%d", x))`, `package p; var _ = fmt.Errorf("%d", x)`, 1},
+		{`package p; var _ = errors.New((fmt.Sprintf("%d %s", x...)))`, `package p; var _ = fmt.Errorf("%d %s", x...)`, 1},
+		{`package p; var _ = errors.New(fmt.Sprintf(f, x))`, `package p; var _ = errors.New(fmt.Sprintf(f, x))`, 0},
+		{`package p; var _ = errors.New(fmt.Sprintf("a: %w", err))`, `package p; var _ = errors.New(fmt.Sprintf("a: %w", err))`, 0},
+		{`package p; var _ = errors.New(fmt.Sprintf("a: %+w", err))`, `package p; var _ = errors.New(fmt.Sprintf("a: %+w", err))`, 0},
+		{"package p; var _ = errors.New(fmt.Sprint(x))", "package p; var _ = errors.New(fmt.Sprint(x))", 0},
+		{"package p; var _ = errors.New(x)", "package p; var _ = errors.New(x)", 0},
+		{`package p; var _ = pkg.New(fmt.Sprintf("a"))`, `package p; var _ = pkg.New(fmt.Sprintf("a"))`, 0},
+		{`package p; func _() { f(errors.New(fmt.Sprintf("a")), errors.New(fmt.Sprintf("b"))) }`, `package p; func _() { f(fmt.Errorf("a"), fmt.Errorf("b")) }`, 2},
+
+		// imports
+		{`package p; import ( "errors"; "fmt" ); var _ = errors.New(fmt.Sprintf("%d", x))`, `package p; import ( "fmt" ); var _ = fmt.Errorf("%d", x)`, 1},
+		{`package p; import "errors"; import "fmt"; var _ = errors.New(fmt.Sprintf("%d", x))`, `package p; import "fmt"; var _ = fmt.Errorf("%d", x)`, 1},
+		{`package p; import ( "errors"; "fmt" ); var _ = errors.New(fmt.Sprintf("%d", x)); var _ = errors.Is`, `package p; import ( "errors"; "fmt" ); var _ = fmt.Errorf("%d", x); var _ = errors.Is`, 1},
+		{`package p; import ( "errors"; "fmt" ); var _ = errors.New(fmt.Sprintf(f, x))`, `package p; import ( "errors"; "fmt" ); var _ = errors.New(fmt.Sprintf(f, x))`, 0},
+	} {
+		testRewrite(t, test.src, test.want, test.count, func(f *File) int { return SimplifyErrorf(f) })
+	}
+}
+
+func TestSimplifyBool(t *testing.T) {
+	for _, test := range []struct {
+		x, want string
+		count   int
+	}{
+		{"x == true", "x", 1},
+		{"true == x", "x", 1},
+		{"x != false", "x", 1},
+		{"x == false", "!x", 1},
+		{"x != (true)", "!x", 1},
+		{"f(x) == false", "!f(x)", 1},
+		{"a < b == false", "!(a < b)", 1},
+		{"(a || b) == false", "!(a || b)", 1},
+		{"!!x", "x", 1},
+		{"!(!x)", "x", 1},
+		{"!!!x", "!x", 1},
+		{"!(x == false)", "x", 2},
+		{"!x == false", "x", 2},
+		{"a && b == true", "a && b", 1},
+		{"(x == true) == false", "!(x)", 2},
+
+		// not simplified
+		{"x == y", "x == y", 0},
+		{"true == false", "true == false", 0},
+		{"!x", "!x", 0},
+		{"-(-x)", "-(-x)", 0},
+	} {
+		src := "package p; var _ = " + test.x
+		want := "package p; var _ = " + test.want
+		testRewrite(t, src, want, test.count, func(f *File) int { return SimplifyBool(f) })
+	}
+}
+
+func TestRenameDecl(t *testing.T) {
+	const src = `package p
+
+func f(x int) int {
+	y := x + 1
+	if x := y; x > 0 {
+		return x
+	}
+	g := func() int { return x * y }
+	x, z := 2, x
+	T{x: x}
+	return x + g() + z + s.x
+}
+`
+	const want = `package p; func f(a int) int { y := a + 1; if x := y; x > 0 { return x }; g := func() int { return a * y }; a, z := 2, a; T{ x: a, }; return a + g() + z + s.x }`
+
+	f := mustParse(t, src, 0)
+	fn := funcDecl(t, f, "f")
+	x := fn.Type.ParamList[0].Name
+	if got := RenameDecl(fn, x, "a"); got != 7 {
+		t.Errorf("got %d renamed identifiers, want 7", got)
+	}
+	if got := lineString(f); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// identifier not declared in fn
+	if got := RenameDecl(fn, NewName(Pos{}, "y"), "b"); got != 0 {
+		t.Errorf("got %d renamed identifiers, want 0", got)
+	}
+}
+
+// rewriteLoops replaces each for loop in f with the result of
+// convert, if any, and returns the number of loops replaced.
+func rewriteLoops(f *File, convert func(*ForStmt) (*ForStmt, bool)) int {
+	count := 0
+	WalkAndChange(f, func(n *Node) bool {
+		if n == nil {
+			return true
+		}
+		if loop, ok := (*n).(*ForStmt); ok {
+			if res, ok := convert(loop); ok {
+				*n = res
+				count++
+			}
+		}
+		return true
+	})
+	return count
+}
+
+func TestCollapseNestedIf(t *testing.T) {
+	for _, test := range []struct {
+		src, want string
+		count     int
+	}{
+		{"if a { if b { f() } }", "if a && b { f() }", 1},
+		{"if a || b { if c == d { f(); g() } }", "if (a || b) && c == d { f(); g() }", 1},
+		{"if a && b { if !c || d {} }", "if a && b && (!c || d) {}", 1},
+		{"if a { if b { if c {} } }", "if a && b { if c {} }", 1},
+		{"if a { if b {}; f() }", "if a { if b {}; f() }", 0},
+		{"if a { if b {} } else {}", "if a { if b {} } else {}", 0},
+		{"if a { if b {} else {} }", "if a { if b {} else {} }", 0},
+		{"if x := f(); x { if b {} }", "if x := f(); x { if b {} }", 0},
+		{"if a { if x := f(); x {} }", "if a { if x := f(); x {} }", 0},
+		{"if a { { if b {} } }", "if a { { if b {} } }", 0},
+	} {
+		src := "package p; func _() { " + test.src + " }"
+		want := "package p; func _() { " + test.want + " }"
+		testRewrite(t, src, want, test.count, func(f *File) int {
+			count := 0
+			WalkAndChange(f, func(n *Node) bool {
+				if n == nil {
+					return true
+				}
+				if ifs, ok := (*n).(*IfStmt); ok {
+					if res, ok := CollapseNestedIf(ifs); ok {
+						*n = res
+						count++
+					}
+				}
+				return true
+			})
+			return count
+		})
+	}
+}
+
+func TestRangeToIndexed(t *testing.T) {
+	for _, test := range []struct {
+		src, want string
+		count     int
+	}{
+		{"for i := range s { f(i) }", "for i := 0; i < len(s); i++ { f(i) }", 1},
+		{"for i, v := range s { f(i, v) }", "for i := 0; i < len(s); i++ { v := s[i]; f(i, v) }", 1},
+		{"for _, v := range x.s { f(v) }", "for i := 0; i < len(x.s); i++ { v := x.s[i]; f(v) }", 1},
+		{"for _, i := range s { f(i) }", "for i1 := 0; i1 < len(s); i1++ { i := s[i1]; f(i) }", 1},
+		{"for range s { f() }", "for i := 0; i < len(s); i++ { f() }", 1},
+		{"for i, _ := range s { for _, v := range s { f(i, v) } }", "for i := 0; i < len(s); i++ { for i1 := 0; i1 < len(s); i1++ { v := s[i1]; f(i, v) } }", 2},
+		{"for i = range s {}", "for i = range s {}", 0},
+		{"for i := range f() {}", "for i := range f() {}", 0},
+		{"for x[0] = range s {}", "for x[0] = range s {}", 0},
+		{"for i := 0; i < n; i++ {}", "for i := 0; i < n; i++ {}", 0},
+	} {
+		src := "package p; func _() { " + test.src + " }"
+		want := "package p; func _() { " + test.want + " }"
+		testRewrite(t, src, want, test.count, func(f *File) int {
+			return rewriteLoops(f, RangeToIndexed)
+		})
+	}
+}
+
+func TestIndexedToRange(t *testing.T) {
+	for _, test := range []struct {
+		src, want string
+		count     int
+	}{
+		{"for i := 0; i < len(s); i++ { f(s[i]) }", "for i := range s { f(s[i]) }", 1},
+		{"for j := 0; j < len(x.s); j += 1 { f(j) }", "for j := range x.s { f(j) }", 1},
+		{"for i := 0; i < len(s); i++ { i++ }", "for i := 0; i < len(s); i++ { i++ }", 0},
+		{"for i := 0; i < len(s); i++ { g(&i) }", "for i := 0; i < len(s); i++ { g(&i) }", 0},
+		{"for i := 0; i < len(s); i++ { i, j = 1, 2 }", "for i := 0; i < len(s); i++ { i, j = 1, 2 }", 0},
+		{"for i := 1; i < len(s); i++ {}", "for i := 1; i < len(s); i++ {}", 0},
+		{"for i := 0; i <= len(s); i++ {}", "for i := 0; i <= len(s); i++ {}", 0},
+		{"for i := 0; i < len(f()); i++ {}", "for i := 0; i < len(f()); i++ {}", 0},
+		{"for i := 0; i < len(s); i += 2 {}", "for i := 0; i < len(s); i += 2 {}", 0},
+		{"for i := 0; i < n; i++ {}", "for i := 0; i < n; i++ {}", 0},
+	} {
+		src := "package p; func _() { " + test.src + " }"
+		want := "package p; func _() { " + test.want + " }"
+		testRewrite(t, src, want, test.count, func(f *File) int {
+			return rewriteLoops(f, IndexedToRange)
+		})
+	}
+}
+
+func TestRangeToIndexedBranches(t *testing.T) {
+	const src = "package p; func _() { L: for _, v := range s { if v { continue L }; break } }"
+	f, err := Parse(nil, strings.NewReader(src), nil, nil, CheckBranches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res *ForStmt
+	rewriteLoops(f, func(loop *ForStmt) (*ForStmt, bool) {
+		var ok bool
+		res, ok = RangeToIndexed(loop)
+		return res, ok
+	})
+	Inspect(f, func(n Node) bool {
+		if b, ok := n.(*BranchStmt); ok && b.Target != res {
+			t.Errorf("%s: got target %v, want converted loop", String(b), b.Target)
+		}
+		return true
+	})
+}
+
+func TestExtractBlock(t *testing.T) {
+	const src = `package p
+
+func f(a int, s ...string) {
+	var x, y int
+	z := 0
+	x = a
+	if x > 0 {
+		print(x, y, s)
+	}
+	for i := range s {
+		if i > 0 {
+			break
+		}
+		continue
+	}
+	return
+}
+`
+	for _, test := range []struct {
+		first, n int
+		want     string // extracted function, or "" if extraction fails
+		body     string // resulting body of f
+	}{
+		{
+			3, 1,
+			"func g(x int, y int, s []string) { if x > 0 { print(x, y, s) } }",
+			"{ var x, y int; z := 0; x = a; g(x, y, s); for i := range s { if i > 0 { break }; continue }; return }",
+		},
+		{
+			4, 1,
+			"func g(s []string) { for i := range s { if i > 0 { break }; continue } }",
+			"{ var x, y int; z := 0; x = a; if x > 0 { print(x, y, s) }; g(s); return }",
+		},
+		{1, 1, "func g() { z := 0 }", ""},
+		{0, 1, "", ""}, // x and y used afterwards
+		{2, 2, "", ""}, // assignment to x
+		{5, 1, "", ""}, // return
+		{4, 2, "", ""}, // return
+		{2, 0, "", ""}, // empty
+	} {
+		f := mustParse(t, src, 0)
+		fn := funcDecl(t, f, "f")
+		decl, call, ok := ExtractBlock(fn, fn.Body.List[test.first:test.first+test.n], "g")
+		if !ok {
+			if test.want != "" {
+				t.Errorf("%d:%d: extraction failed", test.first, test.n)
+			}
+			continue
+		}
+		if test.want == "" {
+			t.Errorf("%d:%d: extraction succeeded: %s", test.first, test.n, lineString(decl))
+			continue
+		}
+		if got := lineString(decl); got != test.want {
+			t.Errorf("%d:%d: got  %s\nwant %s", test.first, test.n, got, test.want)
+		}
+		if test.body != "" {
+			if got := lineString(fn.Body); got != test.body {
+				t.Errorf("%d:%d: got body  %s\nwant body %s", test.first, test.n, got, test.body)
+			}
+		}
+		if call.Fun.(*Name).Value != "g" {
+			t.Errorf("%d:%d: got call %s", test.first, test.n, lineString(call))
+		}
+	}
+
+	// unlabeled branches must be enclosed in the statements
+	f := mustParse(t, src, 0)
+	fn := funcDecl(t, f, "f")
+	loop := fn.Body.List[4].(*ForStmt)
+	if _, _, ok := ExtractBlock(fn, loop.Body.List[:1], "g"); ok {
+		t.Errorf("extracted break statement")
+	}
+
+	// the types of free variables must be known
+	f = mustParse(t, "package p; func f() { z := 0; print(z) }", 0)
+	fn = funcDecl(t, f, "f")
+	if _, _, ok := ExtractBlock(fn, fn.Body.List[1:], "g"); ok {
+		t.Errorf("extracted statement using variable of unknown type")
+	}
+
+	// the statements must not modify free variables, and
+	// their declarations must not be used after them
+	for _, test := range []struct {
+		stmt string
+		ok   bool
+	}{
+		{"print(x, t.f, a[0])", true},
+		{"var y int; print(y)", true},
+		{"_ = func() { x := 1; x++ }", true},
+		{"x++", false},
+		{"x += 1", false},
+		{"t.f = 1", false},
+		{"a[0] = 1", false},
+		{"p := &x; print(p)", false},
+		{"for x = range a {}", false},
+		{"_ = func() { x = 1 }", false},
+		{"var w int; print(w)", false},
+	} {
+		src := "package p; func f(a []int) { var x int; var t T; " + test.stmt + "; print(x, t, w) }"
+		f := mustParse(t, src, 0)
+		fn := funcDecl(t, f, "f")
+		list := fn.Body.List[2 : len(fn.Body.List)-1]
+		if _, _, ok := ExtractBlock(fn, list, "g"); ok != test.ok {
+			t.Errorf("%s: got %v, want %v", test.stmt, ok, test.ok)
+		}
+	}
+}
+
+func TestMergeVarDecls(t *testing.T) {
+	for _, test := range []struct {
+		body, want string
+		count      int
+	}{
+		{"var a int; var b, c = 1, 2; var (d string)", "var ( a int; b, c = 1, 2; d string )", 2},
+		{"var (a int; b int); var c int", "var ( a int; b int; c int )", 1},
+		{"var a int; f(); var b int; var c int", "var a int; f(); var ( b int; c int )", 1},
+		{"var a int; const c = 0; var b int", "var a int; const c = 0; var b int", 0},
+		{"var a int; { var b int; var c int }", "var a int; { var b int; var c int }", 0},
+		{"var a int", "var a int", 0},
+	} {
+		src := "package p; func f() { " + test.body + " }"
+		want := "package p; func f() { " + test.want + " }"
+		testRewrite(t, src, want, test.count, func(f *File) int {
+			return MergeVarDecls(f.DeclList[0].(*FuncDecl).Body)
+		})
+	}
+}
+
+func TestAddFirstParam(t *testing.T) {
+	for _, test := range []struct {
+		src, want string
+	}{
+		{"func f() {}", "func f(ctx context.Context) {}"},
+		{"func f(x int, y ...string) {}", "func f(ctx context.Context, x int, y ...string) {}"},
+		{"func f(a, b int) {}", "func f(ctx context.Context, a, b int) {}"},
+		{"func f(int, string) error", "func f(ctx context.Context, _ int, _ string) error"},
+		{"func (t T) f() {}", "func (t T) f(ctx context.Context) {}"},
+	} {
+		src := "package p; " + test.src
+		want := "package p; " + test.want
+		testRewrite(t, src, want, 0, func(f *File) int {
+			typ := mustParse(t, "package p; var _ context.Context", 0).DeclList[0].(*VarDecl).Type
+			AddFirstParam(f.DeclList[0].(*FuncDecl), "ctx", typ)
+			return 0
+		})
+	}
+}
+
+func TestUpdateCallSites(t *testing.T) {
+	const src = "package p; func _() { f(x); g(f()); t.f(1, 2); f[int](y); h(x); _ = func() { f() } }"
+	const want = "package p; func _() { f(ctx, x); g(f(ctx)); t.f(ctx, 1, 2); f[int](ctx, y); h(x); _ = func() { f(ctx) } }"
+	testRewrite(t, src, want, 5, func(f *File) int {
+		return UpdateCallSites(f, "f", NewName(Pos{}, "ctx"))
+	})
+}
+
+func TestInlineVar(t *testing.T) {
+	for _, test := range []struct {
+		body, want string // want is empty if the variable is not inlined
+	}{
+		{"x := a + b; f(x)", "f((a + b))"},
+		{"var x = a; f(x)", "f(a)"},
+		{"x := -a; if x > 0 { return }", "if (-a) > 0 { return }"},
+		{"x := *q; _ = x[0]", "_ = (*q)[0]"},
+		{"x := &v; x.m()", "(&v).m()"},
+		{"a := 1; x := a; a++; f(x)", ""},
+		{"a := 1; x := a; p := &a; f(x, p)", ""},
+		{"a := 1; x := a.b; a.b = 2; f(x)", ""},
+		{"x := g(); f(x)", ""},
+		{"x := a; f(x, x)", ""},
+		{"x := a; x = b; f(x)", ""},
+		{"x := a; { a := 1; f(x, a) }", ""},
+		{"x := a", ""},
+		{"var x int = a; f(x)", ""},
+		{"if x := a; x > 0 { }", ""},
+		{"x := &T{}; f(x)", ""},
+		{"x := T{a}.b; f(x)", ""},
+		{"x := a + b; for { f(x) }", ""},
+		{"x := &T{}; for _, v := range s { list = append(list, x, v) }", ""},
+		{"x := &T{}; g(func() { f(x) })", ""},
+		{"x := a; g(func() { f(x) })", ""},
+		{"for { x := a + b; f(x) }", "for { f((a + b)) }"},
+		{"g(func() { x := a; f(x) })", "g(func() { f(a) })"},
+	} {
+		src := "package p; func f() { " + test.body + " }"
+		f := mustParse(t, src, 0)
+		fn := f.DeclList[0].(*FuncDecl)
+		var decl *Name
+		Inspect(fn.Body, func(n Node) bool {
+			if n, ok := n.(*Name); ok && n.Value == "x" && decl == nil {
+				decl = n
+			}
+			return decl == nil
+		})
+
+		ok, err := InlineVar(fn, decl)
+		if ok != (test.want != "") || ok != (err == nil) {
+			t.Errorf("%s: got %v, %v", test.body, ok, err)
+			continue
+		}
+		want := src
+		if ok {
+			want = "package p; func f() { " + test.want + " }"
+		}
+		if got, want := lineString(f), lineString(mustParse(t, want, 0)); got != want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.body, got, want)
+		}
+	}
+
+	// unary initializers are parenthesized
+	f := mustParse(t, "package p; func f(q *[]int) { p := *q; _ = p[0] }", 0)
+	fn := funcDecl(t, f, "f")
+	if ok, err := InlineVar(fn, fn.Body.List[0].(*AssignStmt).Lhs.(*Name)); !ok {
+		t.Fatal(err)
+	}
+	if got, want := lineString(fn.Body), "{ _ = (*q)[0] }"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestReorderParams(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		perm []int
+		want string // empty if the parameters are not reordered
+	}{
+		{"func f(a int, b string) {}", []int{1, 0}, "func f(b string, a int) {}"},
+		{"func f(a, b int, c string) {}", []int{1, 0, 2}, "func f(b, a int, c string) {}"},
+		{"func f(a, b int, c string) {}", []int{0, 2, 1}, "func f(a int, c string, b int) {}"},
+		{"func f(a, b, c int, d string) {}", []int{3, 0, 1, 2}, "func f(d string, a, b, c int) {}"},
+		{"func f(a, b, c int, d string) {}", []int{0, 3, 2, 1}, "func f(a int, d string, c, b int) {}"},
+		{"func f(int, string)", []int{1, 0}, "func f(string, int)"},
+		{"func f(a int, b string, c ...int)", []int{1, 0, 2}, "func f(b string, a int, c ...int)"},
+		{"func f(a int, b string, c ...int)", []int{2, 0, 1}, ""},
+		{"func f(a int, b string)", []int{0, 0}, ""},
+		{"func f(a int, b string)", []int{1, 2}, ""},
+		{"func f(a int, b string)", []int{0}, ""},
+	} {
+		src := "package p; " + test.src
+		f := mustParse(t, src, 0)
+		fn := f.DeclList[0].(*FuncDecl)
+		ok := ReorderParams(fn, test.perm)
+		if ok != (test.want != "") {
+			t.Errorf("%s %v: got %v", test.src, test.perm, ok)
+			continue
+		}
+		want := src
+		if ok {
+			want = "package p; " + test.want
+		}
+		if got := lineString(f); got != lineString(mustParse(t, want, 0)) {
+			t.Errorf("%s %v: got %s, want %s", test.src, test.perm, got, want)
+		}
+		// the parameters of the result have no type in common with other, non-adjacent parameters
+		for i, p := range fn.Type.ParamList {
+			for j := i + 2; j < len(fn.Type.ParamList); j++ {
+				if fn.Type.ParamList[j].Type == p.Type && fn.Type.ParamList[j-1].Type != p.Type {
+					t.Errorf("%s %v: parameters %d and %d share their type", test.src, test.perm, i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestReorderArgs(t *testing.T) {
+	const src = "package p; func _() { f(a, b, c); f(a, b); f(a, b, c, d); f(a, b, xs...); t.f(1, 2, 3); g(a, b, c); _ = func() { f(x, y, z) } }"
+	for _, test := range []struct {
+		perm  []int
+		want  string
+		count int
+	}{
+		{[]int{1, 0, 2}, "package p; func _() { f(b, a, c); f(b, a); f(b, a, c, d); f(b, a, xs...); t.f(2, 1, 3); g(a, b, c); _ = func() { f(y, x, z) } }", 6},
+		{[]int{2, 0, 1}, "package p; func _() { f(c, a, b); f(a, b); f(a, b, c, d); f(a, b, xs...); t.f(3, 1, 2); g(a, b, c); _ = func() { f(z, x, y) } }", 3},
+		{[]int{0, 0, 1}, src, 0},
+	} {
+		testRewrite(t, src, test.want, test.count, func(f *File) int {
+			return ReorderArgs(f, "f", test.perm)
+		})
+	}
+}
+
+func TestWrapErrorReturns(t *testing.T) {
+	const src = `package p; func f() (int, error) { if err != nil { return 0, err }; _ = func() error { return err }; if x { return 1, (err) }; return g() }`
+	for _, format := range []string{"reading", "reading: %w"} {
+		const want = `package p; func f() (int, error) { if err != nil { return 0, fmt.Errorf("reading: %w", err) }; _ = func() error { return err }; if x { return 1, (err) }; return g() }`
+		testRewrite(t, src, want, 1, func(f *File) int {
+			return WrapErrorReturns(funcDecl(t, f, "f"), format)
+		})
+	}
+
+	const src2 = `package p; func g() error { if err := h(); err != nil { return err }; return nil }`
+	const want2 = `package p; func g() error { if err := h(); err != nil { return fmt.Errorf("g failed (%w)", err) }; return nil }`
+	testRewrite(t, src2, want2, 1, func(f *File) int {
+		return WrapErrorReturns(funcDecl(t, f, "g"), "g failed (%w)")
+	})
+}
+
+func TestKeyStructLit(t *testing.T) {
+	for _, test := range []struct {
+		lit    string
+		fields []string
+		want   string // empty if the literal is not rewritten
+		err    bool
+	}{
+		{`T{1, "a"}`, []string{"x", "y"}, `T{x: 1, y: "a"}`, false},
+		{`pkg.T[int]{f(), T{}}`, []string{"a", "b"}, `pkg.T[int]{a: f(), b: T{}}`, false},
+		{`T{}`, []string{"x"}, "", false},
+		{`T{x: 1}`, []string{"x"}, "", false},
+		{`T{1}`, []string{"x", "y"}, "", true},
+		{`T{1, 2}`, []string{"x"}, "", true},
+		{`T{1, 2}`, []string{"x", "_"}, "", true},
+	} {
+		x := mustParse(t, "package p; var _ = "+test.lit, 0).DeclList[0].(*VarDecl).Values
+		ok, err := KeyStructLit(x.(*CompositeLit), test.fields)
+		if ok != (test.want != "") || (err != nil) != test.err {
+			t.Errorf("%s %v: got %v, %v", test.lit, test.fields, ok, err)
+			continue
+		}
+		want := test.lit
+		if ok {
+			want = test.want
+		}
+		if got, want := lineString(x), lineString(mustParse(t, "package p; var _ = "+want, 0).DeclList[0].(*VarDecl).Values); got != want {
+			t.Errorf("%s %v: got %s, want %s", test.lit, test.fields, got, want)
+		}
+	}
+}
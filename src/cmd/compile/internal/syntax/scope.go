@@ -0,0 +1,970 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements helper functions for scope and
+// declaration analysis on syntax trees.
+
+package syntax
+
+import "slices"
+
+// A predeclKind describes the kind of object denoted
+// by a predeclared identifier.
+type predeclKind int
+
+const (
+	predeclType predeclKind = iota + 1
+	predeclConst
+	predeclNil
+	predeclFunc
+)
+
+// predeclared maps the predeclared identifiers of the universe
+// scope to the kind of object they denote.
+var predeclared = map[string]predeclKind{
+	// types
+	"any":        predeclType,
+	"bool":       predeclType,
+	"byte":       predeclType,
+	"comparable": predeclType,
+	"complex64":  predeclType,
+	"complex128": predeclType,
+	"error":      predeclType,
+	"float32":    predeclType,
+	"float64":    predeclType,
+	"int":        predeclType,
+	"int8":       predeclType,
+	"int16":      predeclType,
+	"int32":      predeclType,
+	"int64":      predeclType,
+	"rune":       predeclType,
+	"string":     predeclType,
+	"uint":       predeclType,
+	"uint8":      predeclType,
+	"uint16":     predeclType,
+	"uint32":     predeclType,
+	"uint64":     predeclType,
+	"uintptr":    predeclType,
+
+	// constants
+	"true":  predeclConst,
+	"false": predeclConst,
+	"iota":  predeclConst,
+
+	// zero value
+	"nil": predeclNil,
+
+	// functions
+	"append":  predeclFunc,
+	"cap":     predeclFunc,
+	"clear":   predeclFunc,
+	"close":   predeclFunc,
+	"complex": predeclFunc,
+	"copy":    predeclFunc,
+	"delete":  predeclFunc,
+	"imag":    predeclFunc,
+	"len":     predeclFunc,
+	"make":    predeclFunc,
+	"max":     predeclFunc,
+	"min":     predeclFunc,
+	"new":     predeclFunc,
+	"panic":   predeclFunc,
+	"print":   predeclFunc,
+	"println": predeclFunc,
+	"real":    predeclFunc,
+	"recover": predeclFunc,
+}
+
+// IsPredeclared reports whether name is a predeclared identifier
+// (such as int, nil, or len) of the universe scope.
+func IsPredeclared(name string) bool {
+	return predeclared[name] != 0
+}
+
+// isPredeclaredType reports whether name is a predeclared type name.
+func isPredeclaredType(name string) bool {
+	return predeclared[name] == predeclType
+}
+
+// ShadowsBuiltin returns the names declared in root by variable or
+// constant declarations, function parameters and results, receivers,
+// and short variable declarations which shadow a predeclared identifier.
+// The names are returned in source order.
+func ShadowsBuiltin(root Node) []*Name {
+	var list []*Name
+	check := func(n *Name) {
+		if n != nil && predeclared[n.Value] != 0 {
+			list = append(list, n)
+		}
+	}
+	checkExpr := func(x Expr) {
+		for _, x := range UnpackListExpr(x) {
+			if n, ok := x.(*Name); ok {
+				check(n)
+			}
+		}
+	}
+	checkFields := func(list []*Field) {
+		for _, f := range list {
+			check(f.Name)
+		}
+	}
+
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *ConstDecl:
+			for _, n := range n.NameList {
+				check(n)
+			}
+		case *VarDecl:
+			for _, n := range n.NameList {
+				check(n)
+			}
+		case *FuncDecl:
+			if n.Recv != nil {
+				check(n.Recv.Name)
+			}
+			checkFields(n.Type.ParamList)
+			checkFields(n.Type.ResultList)
+		case *FuncLit:
+			checkFields(n.Type.ParamList)
+			checkFields(n.Type.ResultList)
+		case *AssignStmt:
+			if n.Op == Def {
+				checkExpr(n.Lhs)
+			}
+		case *RangeClause:
+			if n.Def {
+				checkExpr(n.Lhs)
+			}
+		case *TypeSwitchGuard:
+			check(n.Lhs)
+		}
+		return true
+	})
+
+	return list
+}
+
+// DeclScopes maps each identifier declared in fn to the node that
+// introduces its scope: fn itself for the receiver, type parameters,
+// parameters, and results; the respective *BlockStmt for variables,
+// constants, and types declared in a block; the *IfStmt, *ForStmt,
+// or *SwitchStmt for variables declared in the init statement of the
+// statement (or in a range clause); the *SwitchStmt for the symbolic
+// variable of a type switch guard; the *CommClause for variables declared
+// in a select case; and the *FuncLit for the parameters and results of
+// a function literal. Blank identifiers and labels are not included.
+func DeclScopes(fn *FuncDecl) map[*Name]Node {
+	m := make(map[*Name]Node)
+	r := resolver{
+		declare: func(name *Name, owner Node) { m[name] = owner },
+	}
+	r.resolve(fn)
+	return m
+}
+
+// FreeIdents returns the declaring identifiers of the local objects of
+// fn (such as its parameters and local variables) which are referred to
+// in the statements of list, which must be part of fn, but declared
+// outside of them, in the order of their first reference. Identifiers
+// referring to package-level or predeclared objects are not included.
+func FreeIdents(fn *FuncDecl, list []Stmt) []*Name {
+	inside := make(map[*Name]bool)
+	for _, s := range list {
+		Inspect(s, func(n Node) bool {
+			if n, ok := n.(*Name); ok {
+				inside[n] = true
+			}
+			return true
+		})
+	}
+
+	var free []*Name
+	seen := make(map[*Name]bool)
+	r := resolver{
+		use: func(name, decl *Name) {
+			if inside[name] && decl != nil && !inside[decl] && !seen[decl] {
+				seen[decl] = true
+				free = append(free, decl)
+			}
+		},
+	}
+	r.resolve(fn)
+	return free
+}
+
+// A Capture describes the local variables captured by
+// a goroutine running a function literal.
+type Capture struct {
+	Go   *CallStmt // go statement starting the goroutine
+	Func *FuncLit  // function literal run by the goroutine
+	Vars []*Name   // declaring identifiers of the captured variables
+}
+
+// GoroutineCaptures returns, for each go statement in fn calling a
+// function literal, as in go func() { ... }(), the local variables (and
+// parameters) of fn which the function literal refers to but which are
+// declared outside of it, in the order of their first reference, in the
+// order of the go statements. Since captured variables are shared with
+// the goroutine rather than copied, accesses to them from the goroutine
+// and the enclosing function may race. Variables passed as arguments of
+// the call are evaluated when the go statement is executed and are not
+// captures (unless referred to in the function literal as well); captures
+// of package-level variables are not included. Go statements whose
+// function literal does not capture any variables are included with
+// empty Vars.
+func GoroutineCaptures(fn *FuncDecl) []Capture {
+	if fn.Body == nil {
+		return nil
+	}
+
+	var list []Capture
+	inside := make(map[*Name][]int) // identifier -> indices of the function literals containing it
+	Inspect(fn.Body, func(n Node) bool {
+		s, ok := n.(*CallStmt)
+		if !ok || s.Tok != _Go {
+			return true
+		}
+		call, ok := Unparen(s.Call).(*CallExpr)
+		if !ok {
+			return true
+		}
+		if lit, ok := Unparen(call.Fun).(*FuncLit); ok {
+			i := len(list)
+			list = append(list, Capture{Go: s, Func: lit})
+			Inspect(lit, func(n Node) bool {
+				if n, ok := n.(*Name); ok {
+					inside[n] = append(inside[n], i)
+				}
+				return true
+			})
+		}
+		return true
+	})
+
+	r := resolver{
+		use: func(name, decl *Name) {
+			if decl == nil {
+				return
+			}
+			for _, i := range inside[name] {
+				c := &list[i]
+				if !slices.Contains(inside[decl], i) && !slices.Contains(c.Vars, decl) {
+					c.Vars = append(c.Vars, decl)
+				}
+			}
+		},
+	}
+	r.resolve(fn)
+	return list
+}
+
+// UnusedVars returns the variables declared in the body of fn, with a
+// variable declaration or a short variable declaration (including those
+// in function literals), which are never used, in source order. As for
+// the compiler, assigning to a variable (including with an assignment
+// operation such as x += 1 or x++) does not count as a use. Blank
+// identifiers, parameters and results, the variables declared in the
+// init statement or range clause of for statements, and type switch
+// symbols are not reported.
+func UnusedVars(fn *FuncDecl) []*Name {
+	if fn.Body == nil {
+		return nil
+	}
+
+	// vars is the set of candidate variables; assigned is the set
+	// of identifiers which are assigned to rather than used
+	vars := make(map[*Name]bool)
+	assigned := make(map[*Name]bool)
+	loopVars := make(map[Stmt]bool) // init statements of for statements
+	Inspect(fn.Body, func(n Node) bool {
+		switch n := n.(type) {
+		case *VarDecl:
+			for _, name := range n.NameList {
+				vars[name] = true
+			}
+		case *ForStmt:
+			if n.Init != nil {
+				loopVars[n.Init] = true
+			}
+		case *AssignStmt:
+			for _, x := range UnpackListExpr(n.Lhs) {
+				if name, ok := Unparen(x).(*Name); ok {
+					if n.Op == Def && !loopVars[n] {
+						vars[name] = true
+					}
+					assigned[name] = true
+				}
+			}
+		}
+		return true
+	})
+
+	var list []*Name
+	used := make(map[*Name]bool)
+	r := resolver{
+		declare: func(name *Name, _ Node) {
+			if vars[name] {
+				list = append(list, name)
+			}
+		},
+		use: func(name, decl *Name) {
+			if decl != nil && !assigned[name] {
+				used[decl] = true
+			}
+		},
+	}
+	r.resolve(fn)
+
+	return slices.DeleteFunc(list, func(name *Name) bool { return used[name] })
+}
+
+// UnusedInitVars returns the variables declared by the init statements
+// of the if, for, and switch statements in the tree rooted at root which
+// are not used in the respective statement, in source order. As for
+// UnusedVars, assignments to a variable are not uses of it; for instance,
+// the variable i in
+//
+//	for i := 0; ; i++ { ... }
+//
+// is unused unless it is used in the loop body.
+func UnusedInitVars(root Node) []*Name {
+	// vars is the set of candidate variables; assigned is the set
+	// of identifiers which are assigned to rather than used
+	vars := make(map[*Name]bool)
+	assigned := make(map[*Name]bool)
+	Inspect(root, func(n Node) bool {
+		var init SimpleStmt
+		switch n := n.(type) {
+		case *IfStmt:
+			init = n.Init
+		case *ForStmt:
+			init = n.Init
+		case *SwitchStmt:
+			init = n.Init
+		case *AssignStmt:
+			for _, x := range UnpackListExpr(n.Lhs) {
+				if name, ok := Unparen(x).(*Name); ok {
+					assigned[name] = true
+				}
+			}
+		}
+		if s, ok := init.(*AssignStmt); ok && s.Op == Def {
+			for _, x := range UnpackListExpr(s.Lhs) {
+				if name, ok := x.(*Name); ok {
+					vars[name] = true
+				}
+			}
+		}
+		return true
+	})
+	if len(vars) == 0 {
+		return nil
+	}
+
+	var list []*Name
+	used := make(map[*Name]bool)
+	r := resolver{
+		declare: func(name *Name, _ Node) {
+			if vars[name] {
+				list = append(list, name)
+			}
+		},
+		use: func(name, decl *Name) {
+			if decl != nil && !assigned[name] {
+				used[decl] = true
+			}
+		},
+	}
+	r.open(root) // scope for declarations at the top of the tree
+	r.resolve(root)
+	r.close()
+
+	return slices.DeleteFunc(list, func(name *Name) bool { return used[name] })
+}
+
+// ShadowedErr returns the variables named err declared by the init
+// statements of if statements in fn which shadow a local variable (or
+// parameter or result) err of an enclosing scope, in source order. For
+// instance, in
+//
+//	func f() (err error) {
+//		if err := g(); err != nil {
+//			return
+//		}
+//		...
+//	}
+//
+// the inner err shadows the result err, which is therefore
+// not set by the return statement as perhaps intended.
+//
+// A variable is only reported if the shadowed err is used after its
+// declaration before it is assigned to, where a bare return statement
+// uses the results of its function. Since there is no control flow
+// analysis, uses and assignments are ordered by their source position.
+func ShadowedErr(fn *FuncDecl) []*Name {
+	// inits is the set of candidate variables
+	inits := make(map[*Name]bool)
+	// assigned is the set of identifiers assigned to
+	assigned := make(map[*Name]bool)
+	assign := func(lhs Expr) {
+		for _, x := range UnpackListExpr(lhs) {
+			if name, ok := Unparen(x).(*Name); ok {
+				assigned[name] = true
+			}
+		}
+	}
+	Inspect(fn, func(n Node) bool {
+		switch n := n.(type) {
+		case *IfStmt:
+			if init, ok := n.Init.(*AssignStmt); ok && init.Op == Def {
+				for _, x := range UnpackListExpr(init.Lhs) {
+					if isNameOf(x, "err") {
+						inits[x.(*Name)] = true
+					}
+				}
+			}
+		case *AssignStmt:
+			if n.Rhs != nil && (n.Op == 0 || n.Op == Def) {
+				assign(n.Lhs)
+			}
+		case *RangeClause:
+			assign(n.Lhs)
+		}
+		return true
+	})
+	if len(inits) == 0 {
+		return nil
+	}
+
+	// An event is a use of (read) or an assignment to
+	// the variable declared by decl at pos.
+	type event struct {
+		pos  Pos
+		decl *Name
+		read bool
+	}
+	var events []event
+	shadowed := make(map[*Name]*Name) // candidate -> shadowed err
+	r := new(resolver)
+	r.declare = func(name *Name, _ Node) {
+		if !inits[name] {
+			return
+		}
+		// name was inserted into the if statement's scope
+		for s := r.scope.parent; s != nil; s = s.parent {
+			if outer := s.names["err"]; outer != nil {
+				shadowed[name] = outer
+				break
+			}
+		}
+	}
+	r.use = func(name, decl *Name) {
+		if decl != nil {
+			events = append(events, event{name.Pos(), decl, !assigned[name]})
+		}
+	}
+	r.resolve(fn)
+	if len(shadowed) == 0 {
+		return nil
+	}
+
+	// bare returns use the named results of their function
+	parents := ParentMap(fn)
+	Inspect(fn, func(n Node) bool {
+		if ret, ok := n.(*ReturnStmt); ok && ret.Results == nil {
+			var typ *FuncType
+			switch f := EnclosingFunc(parents, ret).(type) {
+			case *FuncDecl:
+				typ = f.Type
+			case *FuncLit:
+				typ = f.Type
+			}
+			for _, f := range typ.ResultList {
+				if f.Name != nil {
+					events = append(events, event{ret.Pos(), f.Name, true})
+				}
+			}
+		}
+		return true
+	})
+	slices.SortStableFunc(events, func(a, b event) int { return a.pos.Cmp(b.pos) })
+
+	var list []*Name
+	for name := range inits {
+		outer := shadowed[name]
+		if outer == nil {
+			continue
+		}
+		for _, e := range events {
+			if e.decl == outer && e.pos.Cmp(name.Pos()) > 0 {
+				if e.read {
+					list = append(list, name)
+				}
+				break
+			}
+		}
+	}
+	slices.SortFunc(list, func(a, b *Name) int { return a.Pos().Cmp(b.Pos()) })
+	return list
+}
+
+// A resolver resolves identifiers to their declarations, following
+// the scoping rules of Go, for the local scopes of a syntax tree.
+// Identifiers declared outside the tree, such as package-level or
+// predeclared objects, cannot be resolved. Since there is no type
+// information, keys of composite literals whose type is not a map,
+// array, or slice type are assumed to be struct field names.
+type resolver struct {
+	scope *scope
+
+	// declare, if set, is called for each declared, non-blank identifier
+	// with the node introducing the identifier's scope.
+	declare func(name *Name, owner Node)
+
+	// use, if set, is called for each identifier denoting an object
+	// (not a field, method, package-qualified name, or label), with
+	// the declaring identifier, or nil if the declaration is outside
+	// the tree.
+	use func(name, decl *Name)
+}
+
+// A scope maps names to their declaring identifiers.
+type scope struct {
+	parent *scope
+	owner  Node
+	names  map[string]*Name
+}
+
+func (r *resolver) open(owner Node) {
+	r.scope = &scope{parent: r.scope, owner: owner}
+}
+
+func (r *resolver) close() {
+	r.scope = r.scope.parent
+}
+
+// insert inserts name into the current scope without reporting it.
+func (r *resolver) insert(name *Name) {
+	if name == nil || name.Value == "_" {
+		return
+	}
+	s := r.scope
+	if s.names == nil {
+		s.names = make(map[string]*Name)
+	}
+	s.names[name.Value] = name
+}
+
+// declareName declares name in the current scope.
+func (r *resolver) declareName(name *Name) {
+	if name == nil || name.Value == "_" {
+		return
+	}
+	r.insert(name)
+	if r.declare != nil {
+		r.declare(name, r.scope.owner)
+	}
+}
+
+// lookup returns the declaring identifier for the given name, or nil.
+func (r *resolver) lookup(name string) *Name {
+	for s := r.scope; s != nil; s = s.parent {
+		if decl := s.names[name]; decl != nil {
+			return decl
+		}
+	}
+	return nil
+}
+
+func (r *resolver) useName(name *Name) {
+	if name.Value == "_" {
+		return
+	}
+	if r.use != nil {
+		r.use(name, r.lookup(name.Value))
+	}
+}
+
+// resolve resolves all identifiers in n.
+func (r *resolver) resolve(n Node) {
+	switch n := n.(type) {
+	case nil:
+		// nothing to do (absent optional node)
+
+	case *Name:
+		r.useName(n)
+
+	// declarations
+	case *ConstDecl:
+		r.resolve(n.Type)
+		r.resolve(n.Values)
+		r.declareNames(n.NameList)
+
+	case *VarDecl:
+		r.resolve(n.Type)
+		r.resolve(n.Values)
+		r.declareNames(n.NameList)
+
+	case *TypeDecl:
+		r.declareName(n.Name)
+		r.open(n)
+		r.typeParams(n.TParamList)
+		r.resolve(n.Type)
+		r.close()
+
+	case *FuncDecl:
+		r.open(n)
+		if n.Recv != nil {
+			r.resolve(n.Recv.Type)
+			r.declareName(n.Recv.Name)
+		}
+		r.typeParams(n.TParamList)
+		r.signature(n.Type)
+		r.funcBody(n.Body)
+		r.close()
+
+	// expressions
+	case *CompositeLit:
+		r.resolve(n.Type)
+		keys := false // whether keys are expressions
+		switch n.Type.(type) {
+		case *MapType, *ArrayType, *SliceType:
+			keys = true
+		}
+		for _, x := range n.ElemList {
+			if kv, ok := x.(*KeyValueExpr); ok {
+				if _, isName := kv.Key.(*Name); !isName || keys {
+					r.resolve(kv.Key)
+				}
+				r.resolve(kv.Value)
+				continue
+			}
+			r.resolve(x)
+		}
+
+	case *FuncLit:
+		r.open(n)
+		r.signature(n.Type)
+		r.funcBody(n.Body)
+		r.close()
+
+	case *SelectorExpr:
+		r.resolve(n.X)
+
+	// types
+	case *StructType:
+		for _, f := range n.FieldList {
+			r.resolve(f.Type)
+		}
+
+	case *InterfaceType:
+		for _, f := range n.MethodList {
+			r.resolve(f.Type)
+		}
+
+	case *FuncType:
+		r.fieldTypes(n.ParamList)
+		r.fieldTypes(n.ResultList)
+
+	case *Field:
+		r.resolve(n.Type)
+
+	// statements
+	case *LabeledStmt:
+		r.resolve(n.Stmt)
+
+	case *BranchStmt:
+		// nothing to do
+
+	case *BlockStmt:
+		r.open(n)
+		for _, s := range n.List {
+			r.resolve(s)
+		}
+		r.close()
+
+	case *AssignStmt:
+		if n.Op != Def {
+			r.resolve(n.Lhs)
+			r.resolve(n.Rhs)
+			break
+		}
+		r.resolve(n.Rhs)
+		r.shortVarDecl(n.Lhs)
+
+	case *IfStmt:
+		r.open(n)
+		r.resolve(n.Init)
+		r.resolve(n.Cond)
+		r.resolve(n.Then)
+		r.resolve(n.Else)
+		r.close()
+
+	case *ForStmt:
+		r.open(n)
+		if rc, ok := n.Init.(*RangeClause); ok {
+			r.resolve(rc.X)
+			if rc.Def {
+				r.shortVarDecl(rc.Lhs)
+			} else {
+				r.resolve(rc.Lhs)
+			}
+		} else {
+			r.resolve(n.Init)
+		}
+		r.resolve(n.Cond)
+		r.resolve(n.Post)
+		r.resolve(n.Body)
+		r.close()
+
+	case *SwitchStmt:
+		r.open(n)
+		r.resolve(n.Init)
+		var lhs *Name // type switch symbol, if any
+		if g, ok := n.Tag.(*TypeSwitchGuard); ok {
+			r.resolve(g.X)
+			lhs = g.Lhs
+			if lhs != nil && lhs.Value != "_" && r.declare != nil {
+				r.declare(lhs, n)
+			}
+		} else {
+			r.resolve(n.Tag)
+		}
+		for _, c := range n.Body {
+			r.resolve(c.Cases)
+			r.open(c)
+			if lhs != nil {
+				// lhs is implicitly declared in each clause
+				r.insert(lhs)
+			}
+			r.stmtList(c.Body)
+			r.close()
+		}
+		r.close()
+
+	case *SelectStmt:
+		for _, c := range n.Body {
+			r.open(c)
+			r.resolve(c.Comm)
+			r.stmtList(c.Body)
+			r.close()
+		}
+
+	default:
+		ChildrenFunc(n, func(c Node) bool {
+			r.resolve(c)
+			return true
+		})
+	}
+}
+
+// funcBody resolves the function body b, if any. The parameters and
+// the top-level declarations of the body share the same (function)
+// scope, but the latter are reported with b as their scope owner.
+func (r *resolver) funcBody(b *BlockStmt) {
+	if b != nil {
+		r.scope.owner = b
+		r.stmtList(b.List)
+	}
+}
+
+func (r *resolver) stmtList(list []Stmt) {
+	for _, s := range list {
+		r.resolve(s)
+	}
+}
+
+func (r *resolver) declareNames(list []*Name) {
+	for _, name := range list {
+		r.declareName(name)
+	}
+}
+
+// shortVarDecl declares the new variables on the lhs of a short
+// variable declaration; the others are redeclarations (uses).
+func (r *resolver) shortVarDecl(lhs Expr) {
+	for _, x := range UnpackListExpr(lhs) {
+		name, ok := x.(*Name)
+		if !ok {
+			r.resolve(x) // invalid, but be robust
+			continue
+		}
+		if r.scope.names[name.Value] != nil {
+			r.useName(name)
+			continue
+		}
+		r.declareName(name)
+	}
+}
+
+// typeParams declares the type parameters in list and
+// resolves their constraints.
+func (r *resolver) typeParams(list []*Field) {
+	for _, f := range list {
+		r.declareName(f.Name)
+	}
+	r.fieldTypes(list)
+}
+
+// signature resolves the parameter and result types of typ
+// and declares the parameter and result names.
+func (r *resolver) signature(typ *FuncType) {
+	r.fieldTypes(typ.ParamList)
+	r.fieldTypes(typ.ResultList)
+	for _, f := range typ.ParamList {
+		r.declareName(f.Name)
+	}
+	for _, f := range typ.ResultList {
+		r.declareName(f.Name)
+	}
+}
+
+// fieldTypes resolves the types of the fields in list. Fields
+// declared in a group share the same type, which is resolved
+// only once.
+func (r *resolver) fieldTypes(list []*Field) {
+	var prev Expr
+	for _, f := range list {
+		if f.Type != prev {
+			r.resolve(f.Type)
+			prev = f.Type
+		}
+	}
+}
+
+// A VarInit describes a variable declared by a VarDecl
+// together with its initialization expression, if any.
+type VarInit struct {
+	Decl  *VarDecl
+	Name  *Name
+	Type  Expr // nil means no explicit type
+	Value Expr // nil means no individual initialization expression
+
+	// Shared reports whether all names of Decl are initialized
+	// by a single multi-valued expression (Decl.Values), as in
+	// var a, b = f() or var v, ok = m[k]. In that case Value is nil.
+	Shared bool
+}
+
+// VarInitializers returns the variables declared by the variable
+// declarations in the tree rooted at root, in source order. Each name
+// is paired with its respective value if the number of names matches
+// the number of values.
+func VarInitializers(root Node) []VarInit {
+	var list []VarInit
+	Inspect(root, func(n Node) bool {
+		d, ok := n.(*VarDecl)
+		if !ok {
+			return true
+		}
+		values := UnpackListExpr(d.Values)
+		shared := len(values) == 1 && len(d.NameList) > 1 && isMultiValued(values[0])
+		for i, name := range d.NameList {
+			v := VarInit{Decl: d, Name: name, Type: d.Type, Shared: shared}
+			if len(values) == len(d.NameList) {
+				v.Value = values[i]
+			}
+			list = append(list, v)
+		}
+		return true
+	})
+	return list
+}
+
+// isMultiValued reports whether x may syntactically produce multiple
+// values: x is a call, or a map index expression, type assertion, or
+// receive operation, which may be used in comma-ok assignments.
+func isMultiValued(x Expr) bool {
+	switch x := Unparen(x).(type) {
+	case *CallExpr, *IndexExpr, *AssertExpr:
+		return true
+	case *Operation:
+		return x.Op == Recv && x.Y == nil
+	}
+	return false
+}
+
+// A NameRole describes the syntactic role of an identifier.
+type NameRole uint8
+
+const (
+	_           NameRole = iota
+	Use                  // identifier refers to a declared entity
+	Declaration          // identifier declares an entity
+	Label                // identifier is a label, declared or used
+)
+
+// ClassifyNames maps each identifier in the tree rooted at root to its
+// role, determined by its position alone. Declarations are the names of
+// constant, type, variable, and function declarations, the local package
+// name of an import, the package name of a file, the names of fields
+// (including parameters, results, receivers, type parameters, and
+// interface methods), the names on the left-hand side of a short variable
+// declaration or range clause using :=, and the symbolic variable of a
+// type switch guard. The labels of labeled statements and branch
+// statements are labels; all other identifiers are uses. Names on the
+// left of := which redeclare an existing variable are still classified
+// as declarations.
+func ClassifyNames(root Node) map[*Name]NameRole {
+	m := make(map[*Name]NameRole)
+	set := func(name *Name, role NameRole) {
+		if name != nil {
+			m[name] = role
+		}
+	}
+	declareList := func(list []*Name) {
+		for _, name := range list {
+			set(name, Declaration)
+		}
+	}
+	declareExpr := func(x Expr) {
+		for _, x := range UnpackListExpr(x) {
+			if name, ok := x.(*Name); ok {
+				set(name, Declaration)
+			}
+		}
+	}
+
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case *File:
+			set(n.PkgName, Declaration)
+		case *ImportDecl:
+			set(n.LocalPkgName, Declaration)
+		case *ConstDecl:
+			declareList(n.NameList)
+		case *TypeDecl:
+			set(n.Name, Declaration)
+		case *VarDecl:
+			declareList(n.NameList)
+		case *FuncDecl:
+			set(n.Name, Declaration)
+		case *Field:
+			set(n.Name, Declaration)
+		case *AssignStmt:
+			if n.Op == Def {
+				declareExpr(n.Lhs)
+			}
+		case *RangeClause:
+			if n.Def {
+				declareExpr(n.Lhs)
+			}
+		case *TypeSwitchGuard:
+			set(n.Lhs, Declaration)
+		case *LabeledStmt:
+			set(n.Label, Label)
+		case *BranchStmt:
+			set(n.Label, Label)
+		case *Name:
+			if _, ok := m[n]; !ok {
+				m[n] = Use
+			}
+		}
+		return true
+	})
+
+	return m
+}
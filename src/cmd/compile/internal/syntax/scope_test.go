@@ -0,0 +1,404 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// names returns the values of the names in list, separated by blanks.
+func names(list []*Name) string {
+	var s []string
+	for _, n := range list {
+		s = append(s, n.Value)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestShadowsBuiltin(t *testing.T) {
+	const src = `package p
+
+const true = false
+var x, len int
+
+func (string T) m(cap int) (error bool) {
+	new := 0
+	for i, copy := range s {}
+	switch real := x.(type) {}
+	_ = func(nil int) {}
+	var y, make = 1, 2
+}
+`
+	f := mustParse(t, src, 0)
+	got := names(ShadowsBuiltin(f))
+	const want = "true len string cap error new copy real nil make"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestDeclScopes(t *testing.T) {
+	const src = `package p
+
+func (r T) f(a, _ int) (res int) {
+	x := 0
+	if y := x; y > 0 {
+		var z int
+		_ = z
+	}
+	for i, v := range a {}
+	switch t := a.(type) {
+	case int:
+		w := t
+	}
+	select {
+	case c := <-ch:
+	}
+	_ = func(q int) {}
+	x, u := 1, 2
+	{
+		x := 0
+	}
+}
+`
+	f := mustParse(t, src, 0)
+	fn := funcDecl(t, f, "f")
+	scopes := DeclScopes(fn)
+
+	// describe summarizes the scopes in source order
+	var got []string
+	Inspect(fn, func(n Node) bool {
+		if name, _ := n.(*Name); name != nil {
+			if owner := scopes[name]; owner != nil {
+				got = append(got, fmt.Sprintf("%s:%T@%d", name.Value, owner, owner.Pos().Line()))
+			}
+		}
+		return true
+	})
+
+	want := []string{
+		"r:*syntax.FuncDecl@3",
+		"a:*syntax.FuncDecl@3",
+		"res:*syntax.FuncDecl@3",
+		"x:*syntax.BlockStmt@3",
+		"y:*syntax.IfStmt@5",
+		"z:*syntax.BlockStmt@5",
+		"i:*syntax.ForStmt@9",
+		"v:*syntax.ForStmt@9",
+		"t:*syntax.SwitchStmt@10",
+		"w:*syntax.CaseClause@11",
+		"c:*syntax.CommClause@15",
+		"q:*syntax.FuncLit@17",
+		"u:*syntax.BlockStmt@3",
+		"x:*syntax.BlockStmt@19",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d declarations, want %d:\n%v", len(got), len(want), got)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("got %s, want %s", got[i], want[i])
+		}
+	}
+}
+
+func TestVarInitializers(t *testing.T) {
+	const src = `package p
+
+var a, b int
+var c, d = 1, 2
+var e, f = g()
+var (
+	h int = 3
+	i, j = 4
+)
+
+func _() {
+	var k = 5
+}
+`
+	f := mustParse(t, src, 0)
+
+	var got []string
+	for _, v := range VarInitializers(f) {
+		s := v.Name.Value
+		if v.Type != nil {
+			s += " " + String(v.Type)
+		}
+		if v.Value != nil {
+			s += " = " + String(v.Value)
+		}
+		if v.Shared {
+			s += " = " + String(v.Decl.Values) + " (shared)"
+		}
+		got = append(got, s)
+	}
+
+	const want = "a int, b int, c = 1, d = 2, e = g() (shared), f = g() (shared), h int = 3, i, j, k = 5"
+	if s := strings.Join(got, ", "); s != want {
+		t.Errorf("got  %s\nwant %s", s, want)
+	}
+}
+
+func TestClassifyNames(t *testing.T) {
+	const src = `package p
+
+import m "math"
+
+type T struct{ f int }
+
+func (r T) g(a int) (b int) {
+	x := m.Pi
+	x, y := a, b
+	x = y
+	for i := range r.f {
+		_ = i
+	}
+	switch v := any(x).(type) {
+	case int:
+		_ = v
+	}
+L:
+	for {
+		break L
+	}
+	return T{f: x}.f
+}
+`
+	f := mustParse(t, src, 0)
+	roles := ClassifyNames(f)
+
+	var got []string
+	Inspect(f, func(n Node) bool {
+		if n, ok := n.(*Name); ok {
+			switch roles[n] {
+			case Use:
+				got = append(got, n.Value)
+			case Declaration:
+				got = append(got, "+"+n.Value)
+			case Label:
+				got = append(got, n.Value+":")
+			default:
+				t.Errorf("%s: %s not classified", n.Pos(), n.Value)
+			}
+		}
+		return true
+	})
+
+	const want = "+p +m +T +f int +r T +g +a int +b int +x m Pi +x +y a b x y +i r f _ i +v any x int _ v L: L: T f x f"
+	if s := strings.Join(got, " "); s != want {
+		t.Errorf("got  %s\nwant %s", s, want)
+	}
+}
+
+func TestFreeIdents(t *testing.T) {
+	const src = `package p
+
+func (r *T) f(a, b int, c ...string) {
+	var x, y int
+	z := 0
+	{
+		x := a + x
+		r.m(x, y, b, c, z)
+		g := func(b int) { _ = b + a }
+		pkg.F(g, global)
+	}
+	_ = y
+}
+`
+	f := mustParse(t, src, 0)
+	fn := funcDecl(t, f, "f")
+	list := fn.Body.List[2].(*BlockStmt).List
+	if got, want := names(FreeIdents(fn, list)), "a x r y b c z"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := names(FreeIdents(fn, list[1:2])), "r x y b c z"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestGoroutineCaptures(t *testing.T) {
+	const src = `package p
+
+func handle(w Writer, r *Request) {
+	id := r.ID
+	for i, v := range items {
+		go func() {
+			process(w, id, v)
+			x := i
+			_ = x
+		}()
+		go func(v int) {
+			log(v, global)
+			go func() { done(v, id) }()
+		}(v)
+	}
+	go (func() {})()
+	go f(id)
+}
+`
+	f := mustParse(t, src, 0)
+	var got []string
+	for _, c := range GoroutineCaptures(funcDecl(t, f, "handle")) {
+		got = append(got, fmt.Sprintf("%d: %s", c.Go.Pos().Line(), names(c.Vars)))
+	}
+	const want = "6: w id v i; 11: id; 13: v id; 16: "
+	if got := strings.Join(got, "; "); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestUnusedVars(t *testing.T) {
+	const src = `package p
+
+func f(unusedParam int) (res int) {
+	var a, b int
+	c := 0
+	d, e := 1, 2
+	d, err := g()
+	x := 0
+	x++
+	y := 0
+	y = 1
+	var _, z = 1, 2
+	for i := 0; i < 10; i++ {
+		k := 1
+		k += 1
+	}
+	for i, v := range s {
+		a := a
+	}
+	var shadow int
+	{
+		shadow := 1
+		_ = shadow
+	}
+	captured := 0
+	go func() {
+		inLit := captured
+	}()
+	p := new(T)
+	p.f = 1
+	switch t := v.(type) {
+	}
+	return b + d
+}
+`
+	f := mustParse(t, src, 0)
+	const want = "c e err x y z k a shadow inLit"
+	if got := names(UnusedVars(funcDecl(t, f, "f"))); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestUnusedInitVars(t *testing.T) {
+	const src = `package p
+
+var _ = func() {
+	if v, ok := m[k]; ok {
+	}
+}
+
+func f() {
+	if a := g(); a > 0 {
+	}
+	if b := g(); true {
+		b = 1
+	}
+	if c, err := g(); err != nil {
+	} else {
+		_ = c
+	}
+	for i := 0; ; i++ {
+	}
+	for j := 0; j < n; j++ {
+	}
+	switch s := g(); {
+	}
+	switch u := g(); x := u.(type) {
+	}
+	if d := 0; true {
+		if d := 1; d > 0 {
+		}
+	}
+}
+`
+	f := mustParse(t, src, 0)
+	const want = "v b i s d"
+	if got := names(UnusedInitVars(f)); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestShadowedErr(t *testing.T) {
+	const src = `package p
+
+func f(x int) (err error) {
+	if err := g(); err != nil {
+		return
+	}
+	if v, err := h(); err != nil {
+		_ = v
+	}
+	if x := g(); x != nil {
+	}
+	return
+}
+
+func g() error {
+	if err := g(); err != nil {
+	}
+	var err error
+	if err := h(); err != nil {
+		log(err)
+	}
+	if err != nil {
+		return err
+	}
+	{
+		if err := h(); err != nil {
+			if err := g(); err != nil {
+			}
+		}
+	}
+	err = h()
+	go func() {
+		if err := g(); err != nil {
+			return
+		}
+	}()
+	return err
+}
+
+func h() (err error) {
+	err = g()
+	if err := g(); err != nil {
+		return err
+	}
+	err, x := h()
+	_ = x
+	return
+}
+`
+	f := mustParse(t, src, 0)
+	for _, test := range []struct {
+		fn   string
+		want string
+	}{
+		{"f", "err@4:5 err@7:8"},
+		{"g", "err@19:5 err@33:6"},
+		{"h", ""},
+	} {
+		var got []string
+		for _, name := range ShadowedErr(funcDecl(t, f, test.fn)) {
+			got = append(got, fmt.Sprintf("%s@%d:%d", name.Value, name.Pos().Line(), name.Pos().Col()))
+		}
+		if got := strings.Join(got, " "); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.fn, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements structural validation of syntax trees.
+
+package syntax
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Validate checks the structural invariants of the syntax tree rooted
+// at root, such as the presence of required (non-nil) fields for each
+// kind of node, and returns an Error describing the first violation
+// found, in pre-order, or nil. Validate is intended to catch malformed
+// trees produced by tree edits; trees produced by the parser for
+// syntactically correct source are always valid.
+func Validate(root Node) error {
+	if isNilNode(root) {
+		return Error{Msg: "nil node"}
+	}
+	return validate(root)
+}
+
+func validate(n Node) error {
+	if msg := malformed(n); msg != "" {
+		return Error{Pos: n.Pos(), Msg: fmt.Sprintf("invalid %s: %s", nodeKind(n), msg)}
+	}
+
+	var err error
+	ChildrenFunc(n, func(c Node) bool {
+		if isNilNode(c) {
+			err = Error{Pos: n.Pos(), Msg: fmt.Sprintf("invalid %s: nil child", nodeKind(n))}
+		} else {
+			err = validate(c)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// malformed returns a description of the first structural problem
+// of n, not considering its children, or the empty string.
+func malformed(n Node) string {
+	switch n := n.(type) {
+	// packages
+	case *File:
+		if n.PkgName == nil {
+			return "missing package name"
+		}
+	case *Directive:
+		if !strings.HasPrefix(n.Text, "//go:") || strings.Contains(n.Text, "\n") {
+			return "not a //go: line comment"
+		}
+
+	// declarations
+	case *ImportDecl:
+		if n.Path == nil {
+			return "missing path"
+		}
+	case *ConstDecl:
+		if len(n.NameList) == 0 {
+			return "missing names"
+		}
+	case *TypeDecl:
+		if n.Name == nil {
+			return "missing name"
+		}
+		if n.Type == nil {
+			return "missing type"
+		}
+	case *VarDecl:
+		if len(n.NameList) == 0 {
+			return "missing names"
+		}
+		if n.Type == nil && n.Values == nil {
+			return "missing type or values"
+		}
+	case *FuncDecl:
+		if n.Name == nil {
+			return "missing name"
+		}
+		if n.Type == nil {
+			return "missing signature"
+		}
+
+	// expressions
+	case *KeyValueExpr:
+		if n.Key == nil || n.Value == nil {
+			return "missing key or value"
+		}
+	case *FuncLit:
+		if n.Type == nil {
+			return "missing signature"
+		}
+		if n.Body == nil {
+			return "missing body"
+		}
+	case *ParenExpr:
+		if n.X == nil {
+			return "missing operand"
+		}
+	case *SelectorExpr:
+		if n.X == nil {
+			return "missing operand"
+		}
+		if n.Sel == nil {
+			return "missing selector"
+		}
+	case *IndexExpr:
+		if n.X == nil {
+			return "missing operand"
+		}
+		if n.Index == nil {
+			return "missing index"
+		}
+	case *SliceExpr:
+		if n.X == nil {
+			return "missing operand"
+		}
+		if n.Full && (n.Index[1] == nil || n.Index[2] == nil) {
+			return "missing index in full slice expression"
+		}
+	case *AssertExpr:
+		if n.X == nil {
+			return "missing operand"
+		}
+		if n.Type == nil {
+			return "missing type"
+		}
+	case *TypeSwitchGuard:
+		if n.X == nil {
+			return "missing operand"
+		}
+	case *Operation:
+		if n.X == nil {
+			return "missing operand"
+		}
+		if n.Op == 0 {
+			return "missing operator"
+		}
+	case *CallExpr:
+		if n.Fun == nil {
+			return "missing function"
+		}
+	case *ListExpr:
+		if len(n.ElemList) < 2 {
+			return "fewer than 2 elements"
+		}
+
+	// types
+	case *ArrayType:
+		if n.Elem == nil {
+			return "missing element type"
+		}
+	case *SliceType:
+		if n.Elem == nil {
+			return "missing element type"
+		}
+	case *DotsType:
+		if n.Elem == nil {
+			return "missing element type"
+		}
+	case *StructType:
+		if len(n.TagList) > len(n.FieldList) {
+			return "more tags than fields"
+		}
+	case *Field:
+		if n.Type == nil {
+			return "missing type"
+		}
+	case *MapType:
+		if n.Key == nil {
+			return "missing key type"
+		}
+		if n.Value == nil {
+			return "missing value type"
+		}
+	case *ChanType:
+		if n.Elem == nil {
+			return "missing element type"
+		}
+
+	// statements
+	case *LabeledStmt:
+		if n.Label == nil {
+			return "missing label"
+		}
+		if n.Stmt == nil {
+			return "missing statement"
+		}
+	case *ExprStmt:
+		if n.X == nil {
+			return "missing expression"
+		}
+	case *SendStmt:
+		if n.Chan == nil {
+			return "missing channel"
+		}
+		if n.Value == nil {
+			return "missing value"
+		}
+	case *AssignStmt:
+		if n.Lhs == nil {
+			return "missing lhs"
+		}
+		if n.Rhs == nil && n.Op != Add && n.Op != Sub {
+			return "missing rhs"
+		}
+	case *BranchStmt:
+		switch n.Tok {
+		case _Break, _Continue, _Fallthrough, _Goto:
+		default:
+			return "invalid token " + n.Tok.String()
+		}
+		if n.Tok == _Goto && n.Label == nil {
+			return "missing label"
+		}
+	case *CallStmt:
+		if n.Tok != _Go && n.Tok != _Defer {
+			return "invalid token " + n.Tok.String()
+		}
+		if n.Call == nil {
+			return "missing call"
+		}
+	case *IfStmt:
+		if n.Cond == nil {
+			return "missing condition"
+		}
+		if n.Then == nil {
+			return "missing then branch"
+		}
+		switch n.Else.(type) {
+		case nil, *IfStmt, *BlockStmt:
+		default:
+			return "else branch must be an if statement or block"
+		}
+	case *ForStmt:
+		if n.Body == nil {
+			return "missing body"
+		}
+	case *RangeClause:
+		if n.X == nil {
+			return "missing range expression"
+		}
+	}
+
+	return ""
+}
+
+// isNilNode reports whether n is nil or a nil pointer.
+func isNilNode(n Node) bool {
+	if n == nil {
+		return true
+	}
+	v := reflect.ValueOf(n)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// nodeKind returns the name of the node type of n, such as "FuncDecl".
+func nodeKind(n Node) string {
+	return reflect.TypeOf(n).Elem().Name()
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	const src = `package p
+
+func f(x T) {
+	x.m()
+	if x {
+	} else {
+	}
+}
+`
+	for _, test := range []struct {
+		edit func(f *File)
+		want string // error message; empty means no error
+	}{
+		{func(f *File) {}, ""},
+		{func(f *File) { f.DeclList[0].(*FuncDecl).Name = nil }, "3:6: invalid FuncDecl: missing name"},
+		{func(f *File) {
+			body := f.DeclList[0].(*FuncDecl).Body
+			body.List[0].(*ExprStmt).X.(*CallExpr).Fun.(*SelectorExpr).Sel = nil
+		}, "4:3: invalid SelectorExpr: missing selector"},
+		{func(f *File) {
+			body := f.DeclList[0].(*FuncDecl).Body
+			body.List[1].(*IfStmt).Else = new(EmptyStmt)
+		}, "5:2: invalid IfStmt: else branch must be an if statement or block"},
+		{func(f *File) {
+			body := f.DeclList[0].(*FuncDecl).Body
+			body.List = append(body.List, nil)
+		}, "3:13: invalid BlockStmt: nil child"},
+	} {
+		f := mustParse(t, src, 0)
+		test.edit(f)
+		got := ""
+		if err := Validate(f); err != nil {
+			err := err.(Error)
+			got = fmt.Sprintf("%d:%d: %s", err.Pos.Line(), err.Pos.Col(), err.Msg)
+		}
+		if got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+}
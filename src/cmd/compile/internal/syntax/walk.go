@@ -6,7 +6,10 @@
 
 package syntax
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // Inspect traverses an AST in pre-order: it starts by calling f(root);
 // root must not be nil. If f returns true, Inspect invokes f recursively
@@ -65,6 +68,9 @@ func (w walker) node(n Node) {
 	switch n := n.(type) {
 	// packages
 	case *File:
+		for _, d := range n.Directives {
+			w.node(d)
+		}
 		w.node(n.PkgName)
 		w.declList(n.DeclList)
 
@@ -296,6 +302,9 @@ func (w walker) node(n Node) {
 		}
 		w.node(n.X)
 
+	case *Comment: // nothing to do
+	case *Directive: // nothing to do
+
 	case *CaseClause:
 		if n.Cases != nil {
 			w.node(n.Cases)
@@ -345,8 +354,285 @@ func (w walker) fieldList(list []*Field) {
 	}
 }
 
+// WalkWithComments is like Walk but it also visits the comments
+// collected in File.Comments (see KeepComments), interleaved in
+// source order with the other nodes: a comment is visited (as a
+// leaf node) immediately before the first node starting after it,
+// or before the final Visit(nil) call of the innermost node that
+// encloses it. Comments are only present if root is a *File.
+func WalkWithComments(root Node, v Visitor) {
+	var list []*Comment
+	if f, _ := root.(*File); f != nil {
+		list = f.Comments
+	}
+	w := &commentWalker{v: v, list: &list}
+	Walk(root, w)
+	for _, c := range list {
+		Walk(c, v)
+	}
+}
+
+// A commentWalker wraps a Visitor and emits pending comments
+// positioned before the next node, or before the end of the
+// node for which it was created.
+type commentWalker struct {
+	v    Visitor
+	list *[]*Comment // pending comments, in source order
+	end  Pos         // end position of enclosing node
+}
+
+func (w *commentWalker) Visit(n Node) Visitor {
+	if n == nil {
+		w.flush(w.end)
+		w.v.Visit(nil)
+		return nil
+	}
+
+	w.flush(StartPos(n))
+	v := w.v.Visit(n)
+	if v == nil {
+		return nil
+	}
+	return &commentWalker{v, w.list, EndPos(n)}
+}
+
+// flush visits all pending comments positioned before pos.
+func (w *commentWalker) flush(pos Pos) {
+	for list := *w.list; len(list) > 0 && list[0].Pos().Cmp(pos) < 0; list = *w.list {
+		*w.list = list[1:]
+		Walk(list[0], w.v)
+	}
+}
+
+// WalkBudget is like Walk but it stops visiting nodes after maxNodes
+// nodes have been visited (calls of Visit with a nil argument are not
+// counted). It returns the number of nodes visited and whether the walk
+// was complete, that is, whether no node was skipped because the budget
+// was exhausted. A walk that needs to be resumed later may use a Walker
+// instead.
+func WalkBudget(root Node, maxNodes int, v Visitor) (visited int, complete bool) {
+	b := &budget{max: maxNodes, complete: true}
+	Walk(root, budgetVisitor{v, b})
+	return b.visited, b.complete
+}
+
+type budget struct {
+	max, visited int
+	complete     bool
+}
+
+type budgetVisitor struct {
+	v Visitor
+	b *budget
+}
+
+func (w budgetVisitor) Visit(n Node) Visitor {
+	if n == nil {
+		w.v.Visit(nil)
+		return nil
+	}
+	if w.b.visited >= w.b.max {
+		w.b.complete = false
+		return nil
+	}
+	w.b.visited++
+	v := w.v.Visit(n)
+	if v == nil {
+		return nil
+	}
+	return budgetVisitor{v, w.b}
+}
+
+// Stats describes a traversal of a syntax tree by WalkStats.
+type Stats struct {
+	Nodes    int            // number of nodes visited
+	MaxDepth int            // maximum depth of a visited node; the root has depth 1
+	Pruned   int            // number of visited nodes whose (non-empty) children were skipped
+	Kinds    map[string]int // number of nodes visited per kind, such as "CallExpr"
+}
+
+// WalkStats is like Walk but it also returns statistics about the
+// traversal, for instance to find out why a visitor is slow. A node is
+// pruned if the visitor returned for it is nil even though the node has
+// children; the nodes in pruned subtrees are not counted.
+func WalkStats(root Node, v Visitor) Stats {
+	s := &Stats{Kinds: make(map[string]int)}
+	Walk(root, statsVisitor{v, s, 0})
+	return *s
+}
+
+type statsVisitor struct {
+	v     Visitor
+	s     *Stats
+	depth int // depth of the parent of the nodes visited
+}
+
+func (w statsVisitor) Visit(n Node) Visitor {
+	if n == nil {
+		w.v.Visit(nil)
+		return nil
+	}
+	depth := w.depth + 1
+	w.s.Nodes++
+	w.s.MaxDepth = max(w.s.MaxDepth, depth)
+	w.s.Kinds[nodeKind(n)]++
+	v := w.v.Visit(n)
+	if v == nil {
+		if !eachChild(n, func(Node) bool { return false }) {
+			w.s.Pruned++
+		}
+		return nil
+	}
+	return statsVisitor{v, w.s, depth}
+}
+
+// WalkRange is like Walk but it only visits the nodes of the tree rooted
+// at root which lie within the (inclusive) position range from start to
+// end, such as the nodes of a selection in an editor. A node lies within
+// the range if its extent, from StartPos(n) to EndPos(n), does. Nodes
+// which only partially overlap the range are not visited, but their
+// children are; nodes outside the range are skipped together with their
+// children. Since EndPos is approximate, so is the extent of a node.
+func WalkRange(root Node, start, end Pos, v Visitor) {
+	Walk(root, rangeVisitor{v, start, end})
+}
+
+// A rangeVisitor is the visitor for nodes which partially overlap
+// the range from start to end, and for the root.
+type rangeVisitor struct {
+	v          Visitor
+	start, end Pos
+}
+
+func (w rangeVisitor) Visit(n Node) Visitor {
+	if n == nil {
+		return nil // the node of w was not visited
+	}
+	from, to := StartPos(n), EndPos(n)
+	switch {
+	case from.Cmp(w.end) > 0 || to.Cmp(w.start) < 0:
+		return nil // outside the range
+	case from.Cmp(w.start) >= 0 && to.Cmp(w.end) <= 0:
+		return w.v.Visit(n) // within the range, and so are the children
+	}
+	return w
+}
+
+// WalkAnnotate traverses the syntax tree rooted at root in pre-order,
+// like Inspect, and returns a side table which maps each node n for
+// which f(n) returns a value and true to that value. Nodes are pointers
+// and thus can be used as map keys; they compare by identity. Shared
+// nodes are annotated with the value computed when they are first
+// reached. See also ParentMap.
+func WalkAnnotate[T any](root Node, f func(n Node) (T, bool)) map[Node]T {
+	m := make(map[Node]T)
+	Inspect(root, func(n Node) bool {
+		if n == nil {
+			return false
+		}
+		if _, ok := m[n]; !ok {
+			if v, ok := f(n); ok {
+				m[n] = v
+			}
+		}
+		return true
+	})
+	return m
+}
+
+// A Walker is a pull-based iterator over the nodes of a syntax tree.
+// It yields the same nodes in the same (pre-)order as Walk but keeps
+// the traversal state explicitly, so that a traversal can be suspended
+// and resumed at any time. The tree must not be modified while it is
+// being iterated over, except for subtrees already yielded and skipped.
+type Walker struct {
+	stack []Node // nodes yet to be yielded, in reverse order
+	last  Node   // last node yielded, if its children were not yet pushed
+}
+
+// NewWalker returns a Walker for the tree rooted at root.
+func NewWalker(root Node) *Walker {
+	if root == nil {
+		panic("nil node")
+	}
+	return &Walker{stack: []Node{root}}
+}
+
+// Next returns the next node in pre-order and true,
+// or nil and false if the traversal is complete.
+func (w *Walker) Next() (Node, bool) {
+	if w.last != nil {
+		// push children of last node in reverse order
+		i := len(w.stack)
+		ChildrenFunc(w.last, func(c Node) bool {
+			w.stack = append(w.stack, c)
+			return true
+		})
+		for j := len(w.stack) - 1; i < j; i, j = i+1, j-1 {
+			w.stack[i], w.stack[j] = w.stack[j], w.stack[i]
+		}
+		w.last = nil
+	}
+
+	if len(w.stack) == 0 {
+		return nil, false
+	}
+	n := w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+	w.last = n
+	return n, true
+}
+
+// SkipChildren causes the next call of Next to skip
+// the children of the node returned most recently.
+func (w *Walker) SkipChildren() {
+	w.last = nil
+}
+
+// WalkChan starts a goroutine which traverses the syntax tree rooted at
+// root in pre-order, like Walk, and sends the nodes to the returned
+// channel. The channel is closed when the traversal is complete. The
+// receiver must receive all nodes; otherwise the goroutine is blocked
+// forever. Use WalkChanContext to stop a traversal early. The tree must
+// not be modified until the traversal is complete.
+func WalkChan(root Node) <-chan Node {
+	return WalkChanContext(context.Background(), root)
+}
+
+// WalkChanContext is like WalkChan but stops the traversal and closes
+// the channel when ctx is done. After ctx is done, the receiver may still
+// receive at most one more node.
+func WalkChanContext(ctx context.Context, root Node) <-chan Node {
+	w := NewWalker(root)
+	ch := make(chan Node)
+	go func() {
+		defer close(ch)
+		for n, ok := w.Next(); ok; n, ok = w.Next() {
+			select {
+			case ch <- n:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// WalkAndChange traverses the syntax tree rooted at root in pre-order.
+// For each node, it calls f with a pointer to the node, through which f
+// may replace the node; if f returns true, WalkAndChange then traverses
+// the children of the (possibly replaced) node and calls f(nil) at the
+// end. The result is the (possibly replaced or deleted) root.
+//
+// Setting *n to nil deletes the node: its children are not traversed,
+// there is no call of f(nil) for it, and the respective field of the
+// parent is set to nil. Only optional fields, which may be nil (such as
+// IfStmt.Else, ConstDecl.Type, or FuncDecl.Body), can be deleted;
+// deleting a required field or an element of a node list panics. A
+// deletion may leave the parent in an invalid state (for instance,
+// deleting the Rhs of a regular assignment); see Validate.
 func WalkAndChange(root Node, f func(*Node) bool) Node {
-	return ASTChanger{changer(f)}.node(root)
+	return ASTChanger{changer(f)}.change(root)
 }
 
 type changer func(*Node) bool
@@ -366,36 +652,60 @@ type ASTChanger struct {
 	changer NodeChanger
 }
 
+// node changes the required node o and returns the resulting node.
 func (c ASTChanger) node(o Node) Node {
+	x := c.change(o)
+	if x == nil {
+		panic(fmt.Sprintf("%s: cannot delete required %s", o.Pos(), nodeKind(o)))
+	}
+	return x
+}
+
+// optional is like c.node for an optional (non-nil) node n,
+// but the result is nil if the node was deleted.
+func optional[N Node](c ASTChanger, n N) N {
+	if x := c.change(n); x != nil {
+		return x.(N)
+	}
+	var zero N
+	return zero
+}
+
+// change changes o and its children and returns the resulting
+// node, which is nil if o was deleted.
+func (c ASTChanger) change(o Node) Node {
 	if o == nil {
 		panic("nil node")
 	}
 
 	c.changer = c.changer.Change(&o)
-	if c.changer == nil {
+	if c.changer == nil || o == nil {
 		return o
 	}
 
 	switch n := (o).(type) {
 	// packages
 	case *File:
+		for i, d := range n.Directives {
+			n.Directives[i] = c.node(d).(*Directive)
+		}
 		n.PkgName = c.node(n.PkgName).(*Name)
 		n.DeclList = c.declList(n.DeclList)
 
 	// declarations
 	case *ImportDecl:
 		if n.LocalPkgName != nil {
-			n.LocalPkgName = c.node(n.LocalPkgName).(*Name)
+			n.LocalPkgName = optional(c, n.LocalPkgName)
 		}
 		n.Path = c.node(n.Path).(*BasicLit)
 
 	case *ConstDecl:
 		n.NameList = c.nameList(n.NameList)
 		if n.Type != nil {
-			n.Type = c.node(n.Type).(Expr)
+			n.Type = optional(c, n.Type)
 		}
 		if n.Values != nil {
-			n.Values = c.node(n.Values).(Expr)
+			n.Values = optional(c, n.Values)
 		}
 
 	case *TypeDecl:
@@ -406,21 +716,21 @@ func (c ASTChanger) node(o Node) Node {
 	case *VarDecl:
 		n.NameList = c.nameList(n.NameList)
 		if n.Type != nil {
-			n.Type = c.node(n.Type).(Expr)
+			n.Type = optional(c, n.Type)
 		}
 		if n.Values != nil {
-			n.Values = c.node(n.Values).(Expr)
+			n.Values = optional(c, n.Values)
 		}
 
 	case *FuncDecl:
 		if n.Recv != nil {
-			n.Recv = c.node(n.Recv).(*Field)
+			n.Recv = optional(c, n.Recv)
 		}
 		n.Name = c.node(n.Name).(*Name)
 		n.TParamList = c.fieldList(n.TParamList)
 		n.Type = c.node(n.Type).(*FuncType)
 		if n.Body != nil {
-			n.Body = c.node(n.Body).(*BlockStmt)
+			n.Body = optional(c, n.Body)
 		}
 
 	// expressions
@@ -430,7 +740,7 @@ func (c ASTChanger) node(o Node) Node {
 
 	case *CompositeLit:
 		if n.Type != nil {
-			n.Type = c.node(n.Type).(Expr)
+			n.Type = optional(c, n.Type)
 		}
 		n.ElemList = c.exprList(n.ElemList)
 
@@ -457,7 +767,7 @@ func (c ASTChanger) node(o Node) Node {
 		n.X = c.node(n.X).(Expr)
 		for i, x := range n.Index {
 			if x != nil {
-				n.Index[i] = c.node(x).(Expr)
+				n.Index[i] = optional(c, x)
 			}
 		}
 
@@ -467,14 +777,14 @@ func (c ASTChanger) node(o Node) Node {
 
 	case *TypeSwitchGuard:
 		if n.Lhs != nil {
-			n.Lhs = c.node(n.Lhs).(*Name)
+			n.Lhs = optional(c, n.Lhs)
 		}
 		n.X = c.node(n.X).(Expr)
 
 	case *Operation:
 		n.X = c.node(n.X).(Expr)
 		if n.Y != nil {
-			n.Y = c.node(n.Y).(Expr)
+			n.Y = optional(c, n.Y)
 		}
 
 	case *CallExpr:
@@ -487,7 +797,7 @@ func (c ASTChanger) node(o Node) Node {
 	// types
 	case *ArrayType:
 		if n.Len != nil {
-			n.Len = c.node(n.Len).(Expr)
+			n.Len = optional(c, n.Len)
 		}
 		n.Elem = c.node(n.Elem).(Expr)
 
@@ -501,13 +811,13 @@ func (c ASTChanger) node(o Node) Node {
 		n.FieldList = c.fieldList(n.FieldList)
 		for i, t := range n.TagList {
 			if t != nil {
-				n.TagList[i] = c.node(t).(*BasicLit)
+				n.TagList[i] = optional(c, t)
 			}
 		}
 
 	case *Field:
 		if n.Name != nil {
-			n.Name = c.node(n.Name).(*Name)
+			n.Name = optional(c, n.Name)
 		}
 		n.Type = c.node(n.Type).(Expr)
 
@@ -548,12 +858,12 @@ func (c ASTChanger) node(o Node) Node {
 	case *AssignStmt:
 		n.Lhs = c.node(n.Lhs).(Expr)
 		if n.Rhs != nil {
-			n.Rhs = c.node(n.Rhs).(Expr)
+			n.Rhs = optional(c, n.Rhs)
 		}
 
 	case *BranchStmt:
 		if n.Label != nil {
-			n.Label = c.node(n.Label).(*Name)
+			n.Label = optional(c, n.Label)
 		}
 		// Target points to nodes elsewhere in the syntax tree
 
@@ -562,38 +872,37 @@ func (c ASTChanger) node(o Node) Node {
 
 	case *ReturnStmt:
 		if n.Results != nil {
-			n.Results = c.node(n.Results).(Expr)
+			n.Results = optional(c, n.Results)
 		}
 
 	case *IfStmt:
 		if n.Init != nil {
-			v := c.node(n.Init).(SimpleStmt)
-			n.Init = v
+			n.Init = optional(c, n.Init)
 		}
 		n.Cond = c.node(n.Cond).(Expr)
 		n.Then = c.node(n.Then).(*BlockStmt)
 		if n.Else != nil {
-			n.Else = c.node(n.Else).(Stmt)
+			n.Else = optional(c, n.Else)
 		}
 
 	case *ForStmt:
 		if n.Init != nil {
-			n.Init = c.node(n.Init).(SimpleStmt)
+			n.Init = optional(c, n.Init)
 		}
 		if n.Cond != nil {
-			n.Cond = c.node(n.Cond).(Expr)
+			n.Cond = optional(c, n.Cond)
 		}
 		if n.Post != nil {
-			n.Post = c.node(n.Post).(SimpleStmt)
+			n.Post = optional(c, n.Post)
 		}
 		n.Body = c.node(n.Body).(*BlockStmt)
 
 	case *SwitchStmt:
 		if n.Init != nil {
-			n.Init = c.node(n.Init).(SimpleStmt)
+			n.Init = optional(c, n.Init)
 		}
 		if n.Tag != nil {
-			n.Tag = c.node(n.Tag).(Expr)
+			n.Tag = optional(c, n.Tag)
 		}
 		for i, s := range n.Body {
 			n.Body[i] = c.node(s).(*CaseClause)
@@ -607,19 +916,22 @@ func (c ASTChanger) node(o Node) Node {
 	// helper nodes
 	case *RangeClause:
 		if n.Lhs != nil {
-			n.Lhs = c.node(n.Lhs).(Expr)
+			n.Lhs = optional(c, n.Lhs)
 		}
 		n.X = c.node(n.X).(Expr)
 
+	case *Comment: // nothing to do
+	case *Directive: // nothing to do
+
 	case *CaseClause:
 		if n.Cases != nil {
-			n.Cases = c.node(n.Cases).(Expr)
+			n.Cases = optional(c, n.Cases)
 		}
 		n.Body = c.stmtList(n.Body)
 
 	case *CommClause:
 		if n.Comm != nil {
-			n.Comm = c.node(n.Comm).(SimpleStmt)
+			n.Comm = optional(c, n.Comm)
 		}
 		n.Body = c.stmtList(n.Body)
 
@@ -665,3 +977,241 @@ func (c ASTChanger) fieldList(list []*Field) []*Field {
 	}
 	return list
 }
+
+// Children returns the non-nil direct children of n, in the
+// order in which Walk visits them.
+func Children(n Node) []Node {
+	var list []Node
+	ChildrenFunc(n, func(c Node) bool {
+		list = append(list, c)
+		return true
+	})
+	return list
+}
+
+// ChildrenFunc calls yield for each non-nil direct child of n, in
+// the order in which Walk visits them, until yield returns false.
+// Unlike Children, ChildrenFunc does not allocate.
+func ChildrenFunc(n Node, yield func(Node) bool) {
+	eachChild(n, yield)
+}
+
+// eachChild is like ChildrenFunc but reports whether all
+// children were yielded.
+func eachChild(n Node, yield func(Node) bool) bool {
+	switch n := n.(type) {
+	case nil:
+		panic("nil node")
+
+	// packages
+	case *File:
+		return eachNode(n.Directives, yield) && yield(n.PkgName) && eachNode(n.DeclList, yield)
+
+	// declarations
+	case *ImportDecl:
+		return maybe(n.LocalPkgName, yield) && yield(n.Path)
+
+	case *ConstDecl:
+		return eachNode(n.NameList, yield) && maybe(n.Type, yield) && maybe(n.Values, yield)
+
+	case *TypeDecl:
+		return yield(n.Name) && eachNode(n.TParamList, yield) && yield(n.Type)
+
+	case *VarDecl:
+		return eachNode(n.NameList, yield) && maybe(n.Type, yield) && maybe(n.Values, yield)
+
+	case *FuncDecl:
+		return maybe(n.Recv, yield) && yield(n.Name) && eachNode(n.TParamList, yield) &&
+			yield(n.Type) && maybe(n.Body, yield)
+
+	// expressions
+	case *BadExpr, *Name, *BasicLit:
+		return true
+
+	case *CompositeLit:
+		return maybe(n.Type, yield) && eachNode(n.ElemList, yield)
+
+	case *KeyValueExpr:
+		return yield(n.Key) && yield(n.Value)
+
+	case *FuncLit:
+		return yield(n.Type) && yield(n.Body)
+
+	case *ParenExpr:
+		return yield(n.X)
+
+	case *SelectorExpr:
+		return yield(n.X) && yield(n.Sel)
+
+	case *IndexExpr:
+		return yield(n.X) && yield(n.Index)
+
+	case *SliceExpr:
+		return yield(n.X) && maybe(n.Index[0], yield) && maybe(n.Index[1], yield) && maybe(n.Index[2], yield)
+
+	case *AssertExpr:
+		return yield(n.X) && yield(n.Type)
+
+	case *TypeSwitchGuard:
+		return maybe(n.Lhs, yield) && yield(n.X)
+
+	case *Operation:
+		return yield(n.X) && maybe(n.Y, yield)
+
+	case *CallExpr:
+		return yield(n.Fun) && eachNode(n.ArgList, yield)
+
+	case *ListExpr:
+		return eachNode(n.ElemList, yield)
+
+	// types
+	case *ArrayType:
+		return maybe(n.Len, yield) && yield(n.Elem)
+
+	case *SliceType:
+		return yield(n.Elem)
+
+	case *DotsType:
+		return yield(n.Elem)
+
+	case *StructType:
+		if !eachNode(n.FieldList, yield) {
+			return false
+		}
+		for _, t := range n.TagList {
+			if !maybe(t, yield) {
+				return false
+			}
+		}
+		return true
+
+	case *Field:
+		return maybe(n.Name, yield) && yield(n.Type)
+
+	case *InterfaceType:
+		return eachNode(n.MethodList, yield)
+
+	case *FuncType:
+		return eachNode(n.ParamList, yield) && eachNode(n.ResultList, yield)
+
+	case *MapType:
+		return yield(n.Key) && yield(n.Value)
+
+	case *ChanType:
+		return yield(n.Elem)
+
+	// statements
+	case *EmptyStmt:
+		return true
+
+	case *LabeledStmt:
+		return yield(n.Label) && yield(n.Stmt)
+
+	case *BlockStmt:
+		return eachNode(n.List, yield)
+
+	case *ExprStmt:
+		return yield(n.X)
+
+	case *SendStmt:
+		return yield(n.Chan) && yield(n.Value)
+
+	case *DeclStmt:
+		return eachNode(n.DeclList, yield)
+
+	case *AssignStmt:
+		return yield(n.Lhs) && maybe(n.Rhs, yield)
+
+	case *BranchStmt:
+		// Target points to nodes elsewhere in the syntax tree
+		return maybe(n.Label, yield)
+
+	case *CallStmt:
+		return yield(n.Call)
+
+	case *ReturnStmt:
+		return maybe(n.Results, yield)
+
+	case *IfStmt:
+		return maybe(n.Init, yield) && yield(n.Cond) && yield(n.Then) && maybe(n.Else, yield)
+
+	case *ForStmt:
+		return maybe(n.Init, yield) && maybe(n.Cond, yield) && maybe(n.Post, yield) && yield(n.Body)
+
+	case *SwitchStmt:
+		return maybe(n.Init, yield) && maybe(n.Tag, yield) && eachNode(n.Body, yield)
+
+	case *SelectStmt:
+		return eachNode(n.Body, yield)
+
+	// helper nodes
+	case *RangeClause:
+		return maybe(n.Lhs, yield) && yield(n.X)
+
+	case *CaseClause:
+		return maybe(n.Cases, yield) && eachNode(n.Body, yield)
+
+	case *CommClause:
+		return maybe(n.Comm, yield) && eachNode(n.Body, yield)
+
+	case *Comment, *Directive:
+		return true
+
+	default:
+		panic(fmt.Sprintf("internal error: unknown node type %T", n))
+	}
+}
+
+// eachNode calls yield for each node in list until yield returns false.
+// It reports whether all nodes were yielded.
+func eachNode[N Node](list []N, yield func(Node) bool) bool {
+	for _, n := range list {
+		if !yield(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// maybe calls yield for n if n is not nil, and reports the result.
+// The result is true if n is nil.
+func maybe[N interface {
+	comparable
+	Node
+}](n N, yield func(Node) bool) bool {
+	var zero N // nil *Name, nil Expr, etc.
+	if n == zero {
+		return true
+	}
+	return yield(n)
+}
+
+// RewriteBottomUp rewrites the tree rooted at root in post-order: for
+// each node n, it first rewrites the children of n and then replaces n
+// with the result of f(n), which sees the already rewritten children.
+// The result of RewriteBottomUp is the result of f(root). The node
+// returned by f must be non-nil and acceptable in place of n (for
+// instance, an Expr in place of an Expr, or a *Name in place of a
+// *Name); f may return n itself.
+func RewriteBottomUp(root Node, f func(Node) Node) Node {
+	return ASTChanger{&bottomUp{f: f}}.node(root)
+}
+
+// bottomUp is the NodeChanger used by RewriteBottomUp.
+type bottomUp struct {
+	f     func(Node) Node
+	stack []*Node // nodes whose children are being rewritten
+}
+
+func (b *bottomUp) Change(n *Node) NodeChanger {
+	if n != nil {
+		b.stack = append(b.stack, n)
+		return b
+	}
+	// all children of the top-most node are rewritten
+	i := len(b.stack) - 1
+	n = b.stack[i]
+	b.stack = b.stack[:i]
+	*n = b.f(*n)
+	return nil
+}
@@ -0,0 +1,436 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mustParse parses src and fails the test if there are errors.
+func mustParse(t *testing.T, src string, mode Mode) *File {
+	t.Helper()
+	f, err := Parse(nil, strings.NewReader(src), nil, nil, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// funcDecl returns the (first) function named name in f.
+func funcDecl(t *testing.T, f *File, name string) *FuncDecl {
+	t.Helper()
+	for _, d := range f.DeclList {
+		if d, ok := d.(*FuncDecl); ok && d.Name.Value == name {
+			return d
+		}
+	}
+	t.Fatalf("function %s not found", name)
+	return nil
+}
+
+func TestWalkWithComments(t *testing.T) {
+	const src = `// c0
+package p
+
+// c1
+func f( /* c2 */ x int) {
+	_ = x // c3
+	// c4
+}
+
+// c5
+`
+	f := mustParse(t, src, KeepComments)
+	if got := len(f.Comments); got != 6 {
+		t.Fatalf("got %d comments, want 6", got)
+	}
+
+	var trace []string
+	WalkWithComments(f, inspector(func(n Node) bool {
+		switch n := n.(type) {
+		case *Comment:
+			trace = append(trace, n.Text)
+		case *Name:
+			trace = append(trace, n.Value)
+		}
+		return true
+	}))
+
+	got := strings.Join(trace, " ")
+	const want = "// c0 p // c1 f /* c2 */ x int _ x // c3 // c4 // c5"
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	// comments are ignored without KeepComments
+	if f := mustParse(t, src, 0); f.Comments != nil {
+		t.Errorf("got %d comments, want none", len(f.Comments))
+	}
+}
+
+func TestCommentKinds(t *testing.T) {
+	const src = `// c0
+package p
+
+func f( /* c1 */ x int) {
+	/* c2 */ _ = x // c3
+	/* c4
+	*/ _ = x
+	// c5
+}
+
+// c6
+`
+	f := mustParse(t, src, KeepComments)
+	kinds := [...]string{Above: "Above", Below: "Below", Left: "Left", Right: "Right"}
+	var got []string
+	for _, c := range f.Comments {
+		got = append(got, kinds[c.Kind])
+	}
+	const want = "Above Right Left Right Left Below Below"
+	if got := strings.Join(got, " "); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestChildren(t *testing.T) {
+	const src = `package p
+
+func f[P any](x, y int) (z int) {
+	if v := x; v > 0 {
+		return a[1:2:3]
+	} else {
+		select {}
+	}
+	for {}
+}
+`
+	f := mustParse(t, src, 0)
+
+	// The children must be the nodes visited by Walk, in order.
+	Inspect(f, func(n Node) bool {
+		if n == nil {
+			return false
+		}
+		var want []Node
+		Walk(n, inspector(func(m Node) bool {
+			if m == n {
+				return true
+			}
+			if m != nil {
+				want = append(want, m)
+			}
+			return false
+		}))
+		got := Children(n)
+		if len(got) != len(want) {
+			t.Fatalf("%T: got %d children, want %d", n, len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%T: child %d: got %T, want %T", n, i, got[i], want[i])
+			}
+		}
+		return true
+	})
+
+	// ChildrenFunc stops early
+	fn := funcDecl(t, f, "f")
+	var count int
+	ChildrenFunc(fn, func(Node) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Errorf("got %d calls of yield, want 2", count)
+	}
+}
+
+func TestWalker(t *testing.T) {
+	const src = `package p
+
+func f(x int) int {
+	if x > 0 {
+		return g(x - 1)
+	}
+	return 0
+}
+`
+	f := mustParse(t, src, 0)
+
+	// the walker must produce the same nodes as Inspect
+	var want []Node
+	Inspect(f, func(n Node) bool {
+		if n != nil {
+			want = append(want, n)
+		}
+		return true
+	})
+
+	var got []Node
+	w := NewWalker(f)
+	for n, ok := w.Next(); ok; n, ok = w.Next() {
+		got = append(got, n)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d nodes, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("node %d: got %T, want %T", i, got[i], want[i])
+		}
+	}
+
+	// skipping the children of the if statement
+	w = NewWalker(f)
+	count := 0
+	for n, ok := w.Next(); ok; n, ok = w.Next() {
+		if _, ok := n.(*IfStmt); ok {
+			w.SkipChildren()
+		}
+		count++
+	}
+	if want := len(want) - 10; count != want { // if statement has 10 descendants
+		t.Errorf("got %d nodes, want %d", count, want)
+	}
+}
+
+func TestRewriteBottomUp(t *testing.T) {
+	f := mustParse(t, "package p; var _ = (1 + x) * (2 + 3)", 0)
+
+	// replace each operation whose operands are literals with the literal "c";
+	// this succeeds for the outer operation only if the children are
+	// rewritten first
+	var trace []string
+	RewriteBottomUp(f, func(n Node) Node {
+		if op, ok := n.(*Operation); ok {
+			trace = append(trace, String(op))
+			if x, y := Unparen(op.X), Unparen(op.Y); isLit(x) && isLit(y) {
+				return newBasicLit(op.Pos(), "c", IntLit)
+			}
+		}
+		if x, ok := n.(*Name); ok && x.Value == "x" {
+			return newBasicLit(x.Pos(), "0", IntLit)
+		}
+		return n
+	})
+
+	if got, want := lineString(f), "package p; var _ = c"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := strings.Join(trace, ", "), "1 + 0, 2 + 3, (c) * (c)"; got != want {
+		t.Errorf("got trace %s, want %s", got, want)
+	}
+}
+
+func TestWalkBudget(t *testing.T) {
+	f := mustParse(t, "package p; var x = a + b*c", 0)
+	total, _ := WalkBudget(f, 1000, inspector(func(Node) bool { return true }))
+	if total != 9 {
+		t.Fatalf("got %d nodes, want 9", total)
+	}
+
+	for max := 0; max <= total; max++ {
+		var count, ends int
+		visited, complete := WalkBudget(f, max, inspector(func(n Node) bool {
+			if n == nil {
+				ends++
+			} else {
+				count++
+			}
+			return true
+		}))
+		if visited != max || count != max {
+			t.Errorf("max = %d: got %d visited (%d counted), want %d", max, visited, count, max)
+		}
+		if ends != count {
+			t.Errorf("max = %d: got %d nodes but %d nil visits", max, count, ends)
+		}
+		if want := max == total; complete != want {
+			t.Errorf("max = %d: got complete = %v, want %v", max, complete, want)
+		}
+	}
+}
+
+func TestWalkStats(t *testing.T) {
+	f := mustParse(t, "package p; var x = a + b*c; func f() { g(x) }", 0)
+	s := WalkStats(f, inspector(func(Node) bool { return true }))
+	// File > FuncDecl > BlockStmt > ExprStmt > CallExpr > Name
+	if s.Nodes != 17 || s.MaxDepth != 6 || s.Pruned != 0 {
+		t.Errorf("got %d nodes, max depth %d, %d pruned; want 17, 6, 0", s.Nodes, s.MaxDepth, s.Pruned)
+	}
+	if got := fmt.Sprint(s.Kinds); got != "map[BlockStmt:1 CallExpr:1 ExprStmt:1 File:1 FuncDecl:1 FuncType:1 Name:8 Operation:2 VarDecl:1]" {
+		t.Errorf("got kinds %s", got)
+	}
+
+	// prune at function declarations and names (which have no children)
+	s = WalkStats(f, inspector(func(n Node) bool {
+		switch n.(type) {
+		case *FuncDecl, *Name:
+			return false
+		}
+		return true
+	}))
+	if s.Nodes != 10 || s.MaxDepth != 5 || s.Pruned != 1 {
+		t.Errorf("got %d nodes, max depth %d, %d pruned; want 10, 5, 1", s.Nodes, s.MaxDepth, s.Pruned)
+	}
+}
+
+func TestWalkRange(t *testing.T) {
+	const src = `package p
+
+func f() {
+	a := 1
+	if a > 0 {
+		g(a)
+	}
+	h()
+}
+`
+	f := mustParse(t, src, 0)
+	base := f.Pos().Base()
+	for _, test := range []struct {
+		from, to [2]uint // line and column
+		want     string
+	}{
+		{[2]uint{4, 1}, [2]uint{4, 100}, "a := 1 a 1"},
+		{[2]uint{4, 6}, [2]uint{8, 1}, "1 if a > 0 { g(a) } a > 0 a 0 { g(a) } g(a) g(a) g a"},
+		{[2]uint{6, 3}, [2]uint{6, 6}, "g(a) g(a) g a"}, // EndPos(g(a)) is the end of a
+		{[2]uint{5, 1}, [2]uint{5, 1}, ""},
+		{[2]uint{8, 1}, [2]uint{100, 1}, "h() h() h"},
+	} {
+		start := MakePos(base, test.from[0], test.from[1])
+		end := MakePos(base, test.to[0], test.to[1])
+		var got []string
+		ends := 0
+		WalkRange(f, start, end, inspector(func(n Node) bool {
+			if n == nil {
+				ends++
+				return false
+			}
+			got = append(got, lineString(n))
+			return true
+		}))
+		if got := strings.Join(got, " "); got != test.want {
+			t.Errorf("%v-%v: got  %s\nwant %s", start, end, got, test.want)
+		}
+		if ends != len(got) {
+			t.Errorf("%v-%v: got %d nodes but %d nil visits", start, end, len(got), ends)
+		}
+	}
+}
+
+func TestWalkAndChangeDelete(t *testing.T) {
+	const src = `package p; const c int = 1; func f() { if x { a() } else { b() }; g() }`
+	f := mustParse(t, src, 0)
+
+	// delete the explicit constant type and the else branch
+	var del []Node
+	WalkAndChange(f, func(n *Node) bool {
+		if n == nil {
+			return true
+		}
+		switch x := (*n).(type) {
+		case *ConstDecl:
+			del = append(del, x.Type)
+		case *IfStmt:
+			del = append(del, x.Else)
+		}
+		if slices.Contains(del, *n) {
+			*n = nil
+		}
+		return true
+	})
+	if got, want := lineString(f), "package p; const c = 1; func f() { if x { a() }; g() }"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if err := Validate(f); err != nil {
+		t.Errorf("invalid tree after deletion: %v", err)
+	}
+
+	// deleting a required field must panic
+	defer func() {
+		if msg, _ := recover().(string); !strings.Contains(msg, "cannot delete required Name") {
+			t.Errorf("got panic %q, want cannot delete required Name", msg)
+		}
+	}()
+	WalkAndChange(f, func(n *Node) bool {
+		if n != nil {
+			if x, ok := (*n).(*Name); ok && x.Value == "x" {
+				*n = nil
+			}
+		}
+		return true
+	})
+	t.Error("deleting a required field did not panic")
+}
+
+func TestWalkChan(t *testing.T) {
+	f := mustParse(t, "package p; func f(x int) int { return g(x - 1) }", 0)
+
+	var want []Node
+	Inspect(f, func(n Node) bool {
+		if n != nil {
+			want = append(want, n)
+		}
+		return true
+	})
+
+	var got []Node
+	for n := range WalkChan(f) {
+		got = append(got, n)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %d nodes, want %d in the same order", len(got), len(want))
+	}
+
+	// cancelling the context stops the traversal and closes the channel
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := WalkChanContext(ctx, f)
+	if n := <-ch; n != f {
+		t.Errorf("got first node %T, want *File", n)
+	}
+	cancel()
+	count := 0
+	for range ch {
+		count++
+	}
+	if count > 1 {
+		t.Errorf("got %d nodes after cancellation, want at most 1", count)
+	}
+}
+
+func TestWalkAnnotate(t *testing.T) {
+	f := mustParse(t, "package p; func f(a, b int) { g(a + 1) }", 0)
+
+	// annotate each expression with its depth in the tree
+	parents := ParentMap(f)
+	depth := WalkAnnotate(f, func(n Node) (int, bool) {
+		if _, ok := n.(Expr); !ok {
+			return 0, false
+		}
+		d := 0
+		for p := parents[n]; p != nil; p = parents[p] {
+			d++
+		}
+		return d, true
+	})
+
+	var got []string
+	Inspect(f, func(n Node) bool {
+		if d, ok := depth[n]; ok {
+			got = append(got, fmt.Sprintf("%s:%d", String(n), d))
+		}
+		return true
+	})
+	const want = "p:1 f:2 func(a, b int):2 a:4 int:4 b:4 int:4 g(a + 1):4 g:5 a + 1:5 a:6 1:6"
+	if s := strings.Join(got, " "); s != want {
+		t.Errorf("got  %s\nwant %s", s, want)
+	}
+}